@@ -13,8 +13,8 @@ import (
 	_ "BlessedApi/cmd/db"
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/service"
-	"BlessedApi/pkg/binance"
 	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/pricefeed"
 	"BlessedApi/pkg/redis"
 )
 
@@ -28,29 +28,54 @@ func Start() {
 	router.Use(middleware.BlockBadActorsMiddleware())
 	fromTelegram := router.Group("/", middleware.ValidateTelegramInitDataMiddleware())
 	authorized := fromTelegram.Group("/", middleware.AuthMiddleware())
+	manage := router.Group("/", middleware.ManageTokenMiddleware())
 
-	// Initialize Redis and Binance WebSocket services
+	// Initialize Redis and price feed services
 	redisService := redis.NewRedisService("redis:6379", "")
-	logger.Info("Starting Binance WebSocket service...")
-	binanceWS := binance.NewBinanceWebsocketService(redisService)
-	binanceWS.Start()
+	klineStore := pricefeed.NewKlineStore(redisService)
+	priceFeed, err := newPriceFeed(klineStore, service.BinaryInstrumentSymbols())
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+	logger.Info("Starting price feed...")
+	if err := priceFeed.Start(); err != nil {
+		logger.Error("Price feed is not started, binary options have no prices: %v", err)
+	}
+
+	// Suspend binary bets and record gaps while price feed is stale
+	go service.SuperviseBinaryFeedWatchdog(klineStore)
+
+	// Archive klines to Postgres for binary bets audit
+	go service.SuperviseBinaryPriceArchive(klineStore)
+
+	// Settle binary bets, including bets expired while server was down
+	go service.SuperviseBinaryBetSettlement(klineStore)
 
 	// Binary options WebSocket routes
-	apiWebsocketService := service.NewAPIWebsocketServiceBinaryOptions(redisService, binanceWS)
+	apiWebsocketService := service.NewAPIWebsocketServiceBinaryOptions(klineStore, priceFeed)
 	// fromTelegram
 	{
 		fromTelegram.GET(apiPrefix+"ws/kline", apiWebsocketService.WebsocketHandler)
 		fromTelegram.GET(apiPrefix+"ws/kline/latest", apiWebsocketService.LatestKlineWebsocketHandler)
 	}
 
+	// Redis service of the service package, used by Fortune Wheel
+	// and Roulette X14 statistics, must be set before game loops start
+	service.InitFortuneWheelService(redisService)
+
 	// Start the Roulette X14 game loop in a separate goroutine
 	go service.SuperviseRouletteX14Game()
 
 	// Start the Crash Game game loop in a separate goroutine
 	go service.SuperviseCrashGame()
 
+	// Expire user benefits, including ones expired while server was down
+	go service.SuperviseBenefitExpiry()
+
+	// Warn users about soon expiring benefits
+	go service.SuperviseBenefitExpiryNotifications()
+
 	// Fortune Wheel WebSocket routes
-	service.InitFortuneWheelService(redisService)
 	fortuneWheelWebsocketService := service.NewFortuneWheelWebsocketService(redisService)
 
 	// router
@@ -59,12 +84,42 @@ func Start() {
 		router.POST(apiPrefix+"payments/postback", service.PaymentWebhook)
 	}
 
+	// manage
+	{
+		// trave pass
+		manage.GET(apiPrefix+"manage/travepass/seasons", service.ManageGetTravePassSeasons)
+		manage.POST(apiPrefix+"manage/travepass/seasons", service.ManageCreateTravePassSeason)
+		manage.PUT(apiPrefix+"manage/travepass/seasons/:id", service.ManageEditTravePassSeason)
+		manage.GET(apiPrefix+"manage/travepass/seasons/:id/levels", service.ManageGetTravePassSeasonLevels)
+		manage.POST(apiPrefix+"manage/travepass/seasons/:id/levels", service.ManageAddTravePassLevel)
+		manage.PUT(apiPrefix+"manage/travepass/seasons/:id/levels/order", service.ManageReorderTravePassLevels)
+		manage.DELETE(apiPrefix+"manage/travepass/levels/:id", service.ManageDeleteTravePassLevel)
+		manage.POST(apiPrefix+"manage/travepass/levels/:id/requirements", service.ManageAddTravePassLevelRequirement)
+		manage.PUT(apiPrefix+"manage/travepass/requirements/:id", service.ManageEditTravePassRequirement)
+		manage.DELETE(apiPrefix+"manage/travepass/requirements/:id", service.ManageDeleteTravePassRequirement)
+		manage.POST(apiPrefix+"manage/travepass/levels/:id/benefits", service.ManageAddTravePassLevelBenefit)
+		manage.PUT(apiPrefix+"manage/travepass/benefits/:id", service.ManageEditTravePassBenefit)
+		manage.DELETE(apiPrefix+"manage/travepass/benefits/:id", service.ManageDeleteTravePassBenefit)
+
+		// quests
+		manage.GET(apiPrefix+"manage/quests", service.ManageGetQuests)
+		manage.POST(apiPrefix+"manage/quests", service.ManageCreateQuest)
+		manage.DELETE(apiPrefix+"manage/quests/:id", service.ManageDisableQuest)
+
+		// items
+		manage.GET(apiPrefix+"manage/items", service.ManageGetItems)
+		manage.POST(apiPrefix+"manage/items", service.ManageCreateItem)
+		manage.DELETE(apiPrefix+"manage/items/:id", service.ManageDisableItem)
+		manage.GET(apiPrefix+"manage/items/fulfilments", service.ManageGetItemFulfilments)
+		manage.PUT(apiPrefix+"manage/items/fulfilments/:id", service.ManageUpdateItemFulfilment)
+	}
+
 	// fromTelegram
 	{
 		fromTelegram.GET(apiPrefix+"ws/fortunewheel/live", fortuneWheelWebsocketService.LiveWinsWebsocketHandler)
 
 		// Roulette X14 WebSocket routes
-		//fromTelegram.GET(apiPrefix+"ws/roulettex14/live", service.RouletteWebsocketService.LiveRouletteX14WebsocketHandler)
+		fromTelegram.GET(apiPrefix+"ws/roulettex14/live", service.RouletteWebsocketService.LiveRouletteX14WebsocketHandler)
 
 		// Crash Game WebSocket routes
 		fromTelegram.GET(apiPrefix+"ws/crashgame/live", service.CrashGameWS.LiveCrashGameWebsocketHandler)
@@ -82,10 +137,22 @@ func Start() {
 		// trave pass
 		authorized.GET(apiPrefix+"travepass", service.GetAllTravePassLevelsWithRequirementsAndBenefits)
 		authorized.GET(apiPrefix+"travepass/requirements", service.GetNextLevelRequirements)
+		authorized.GET(apiPrefix+"travepass/seasons", service.GetUserTravePassSeasons)
+		authorized.GET(apiPrefix+"travepass/levels/reached", service.GetUnseenTravePassReachedLevels)
+		authorized.POST(apiPrefix+"travepass/premium", service.BuyTravePassPremium)
 
 		// requirements
 		authorized.GET(apiPrefix+"requirements/progress", service.GetUserRequirementsProgress)
 
+		// quests
+		authorized.GET(apiPrefix+"quests", service.GetUserQuests)
+
+		// items
+		authorized.GET(apiPrefix+"items", service.GetUserItems)
+		authorized.GET(apiPrefix+"items/fulfilments", service.GetUserItemFulfilments)
+		authorized.POST(apiPrefix+"items/:id/use", service.UseUserItem)
+		authorized.POST(apiPrefix+"items/:id/redeem", service.RedeemUserItem)
+
 		// exchange
 		authorized.GET(apiPrefix+"users/exchange", service.GetUserExchangeBalance)
 		authorized.POST(apiPrefix+"users/exchange", service.ExchangeBcoinsToRupee)
@@ -125,9 +192,18 @@ func Start() {
 
 		// binary
 		authorized.POST(apiPrefix+"games/binary/place", func(c *gin.Context) {
-			service.PlaceBinaryBet(c, redisService)
+			service.PlaceBinaryBet(c, klineStore)
 		})
 		authorized.GET(apiPrefix+"games/binary/outcome", service.GetUserBetOutcome)
+		authorized.GET(apiPrefix+"games/binary/bets", service.GetUserBinaryBetHistory)
+		authorized.GET(apiPrefix+"games/binary/instruments", service.GetBinaryInstruments)
+		authorized.GET(apiPrefix+"games/binary/feed/status", service.GetBinaryFeedStatus)
+		authorized.GET(apiPrefix+"games/binary/bets/:id/audit", service.GetBinaryBetAudit)
+		authorized.GET(apiPrefix+"games/binary/candles", service.GetBinaryCandles)
+		authorized.POST(apiPrefix+"games/binary/bets/:id/quote", func(c *gin.Context) {
+			service.QuoteBinaryBet(c, klineStore)
+		})
+		authorized.POST(apiPrefix+"games/binary/bets/:id/sell", service.SellBinaryBet)
 		authorized.GET(apiPrefix+"games/binary/benefits",
 			service.GetUserFreeBinaryOptionBets)
 
@@ -135,11 +211,15 @@ func Start() {
 		authorized.POST(apiPrefix+"games/roulettex14/place", service.PlaceRouletteX14Bet)
 		authorized.GET(apiPrefix+"games/roulettex14/info", service.GetRouletteX14Info)
 		authorized.GET(apiPrefix+"games/roulettex14/history", service.GetRouletteX14History)
+		authorized.GET(apiPrefix+"games/roulettex14/stats", service.GetRouletteX14Stats)
 
 		// Crash Game
 		authorized.POST(apiPrefix+"games/crashgame/place", service.PlaceCrashGameBet)
 		authorized.POST(apiPrefix+"games/crashgame/cashout", service.ManualCashout)
 		authorized.GET(apiPrefix+"games/crashgame/history", service.CrashGameWS.GetLast50CrashGames)
+		authorized.GET(apiPrefix+"games/crashgame/autobet", service.GetCrashGameAutoBet)
+		authorized.POST(apiPrefix+"games/crashgame/autobet", service.SetCrashGameAutoBet)
+		authorized.DELETE(apiPrefix+"games/crashgame/autobet", service.CancelCrashGameAutoBet)
 
 		router.GET(apiPrefix+"leaders/get", service.GetLeaders)
 
@@ -181,6 +261,7 @@ func Start() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutdown Server...")
+	priceFeed.Stop()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"BlessedApi/pkg/binance"
+	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/pricefeed"
+)
+
+// newPriceFeed returns price feed selected by PRICE_FEED environment
+// variable: "binance" (default) for live Binance klines, "replay" for
+// klines recorded in PRICE_FEED_REPLAY_FILE and "random" for synthetic
+// random walk. Replay and random feeds don't need network access.
+func newPriceFeed(store *pricefeed.KlineStore, symbols []string) (pricefeed.Feed, error) {
+	feed, ok := os.LookupEnv("PRICE_FEED")
+	if !ok || feed == "" {
+		feed = "binance"
+	}
+
+	switch feed {
+	case "binance":
+		return binance.NewBinanceWebsocketService(store, symbols), nil
+	case "replay":
+		path, ok := os.LookupEnv("PRICE_FEED_REPLAY_FILE")
+		if !ok || path == "" {
+			return nil, logger.WrapError(fmt.Errorf("PRICE_FEED_REPLAY_FILE is required by replay price feed"), "")
+		}
+		return pricefeed.NewReplayFeed(store, path, symbols), nil
+	case "random":
+		return pricefeed.NewRandomWalkFeed(store, symbols), nil
+	}
+
+	return nil, logger.WrapError(fmt.Errorf("unknown price feed %s", feed), "")
+}
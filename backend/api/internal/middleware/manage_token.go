@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ManageTokenMiddleware allows requests with X-Manage-Token header equal
+// to MANAGE_API_TOKEN env. All requests are rejected if env is not set.
+func ManageTokenMiddleware() gin.HandlerFunc {
+	token, ok := os.LookupEnv("MANAGE_API_TOKEN")
+
+	return func(c *gin.Context) {
+		if !ok || token == "" {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			c.Abort()
+			return
+		}
+
+		requestToken := c.GetHeader("X-Manage-Token")
+		if subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) != 1 {
+			c.JSON(401, gin.H{"error": "Invalid manage token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -3,6 +3,8 @@ package benefits
 import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/pkg/logger"
+	"fmt"
+	"reflect"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +16,22 @@ type Benefit struct {
 	PolymorphicBenefit     interface{} `gorm:"-"`
 }
 
+// polymorphicBenefitTypes maps PolymorphicBenefitType to constructor
+// of empty polymorphic benefit. Filled by RegisterBenefitType.
+var polymorphicBenefitTypes = map[string]func() interface{}{}
+
+// RegisterBenefitType registers polymorphic benefit type. newBenefit
+// should return pointer to empty benefit of the type. Types are
+// registered with their progress handlers, see
+// benefit_progress.RegisterBenefitHandler. Panics if type
+// is already registered.
+func RegisterBenefitType(benefitType string, newBenefit func() interface{}) {
+	if _, ok := polymorphicBenefitTypes[benefitType]; ok {
+		panic(fmt.Sprintf("PolymorphicBenefitType %s already registered", benefitType))
+	}
+	polymorphicBenefitTypes[benefitType] = newBenefit
+}
+
 // PreloadPolymorphicBenefit preloads Benefit
 // polymorphic relation PolymorphicBenefit by its type and id.
 func (ben *Benefit) PreloadPolymorphicBenefit(tx *gorm.DB) error {
@@ -21,58 +39,29 @@ func (ben *Benefit) PreloadPolymorphicBenefit(tx *gorm.DB) error {
 		tx = db.DB
 	}
 
-	var err error
-	switch ben.PolymorphicBenefitType {
-	case BenefitClickerType:
-		var clickerBen BenefitClicker
-		err = tx.First(&clickerBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = clickerBen
-	case BenefitCreditType:
-		var creditBen BenefitCredit
-		err = tx.First(&creditBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = creditBen
-	case BenefitMiniGameType:
-		var gameBen BenefitMiniGame
-		err = tx.First(&gameBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = gameBen
-	case BenefitItemType:
-		var itemBen BenefitItem
-		err = tx.First(&itemBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = itemBen
-	case BenefitReplenishmentType:
-		var replenishmentBen BenefitReplenishment
-		err = tx.First(&replenishmentBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = replenishmentBen
-	case BenefitBinaryOptionType:
-		var binaryOptBen BenefitBinaryOption
-		err = tx.First(&binaryOptBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = binaryOptBen
-	case BenefitFortuneWheelType:
-		var fortuneWheelBen BenefitFortuneWheel
-		err = tx.First(&fortuneWheelBen, ben.PolymorphicBenefitID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		ben.PolymorphicBenefit = fortuneWheelBen
+	polymorphicBenefit, err := NewPolymorphicBenefit(ben.PolymorphicBenefitType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.First(polymorphicBenefit, ben.PolymorphicBenefitID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
 	}
 
+	// Benefits are stored by value
+	ben.PolymorphicBenefit = reflect.ValueOf(polymorphicBenefit).Elem().Interface()
+
 	return nil
 }
+
+// NewPolymorphicBenefit returns pointer to empty
+// polymorphic benefit of given type.
+func NewPolymorphicBenefit(benefitType string) (interface{}, error) {
+	newBenefit, ok := polymorphicBenefitTypes[benefitType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicBenefitType: %s", benefitType)
+	}
+
+	return newBenefit(), nil
+}
@@ -4,12 +4,23 @@ import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/models/benefits"
 	"BlessedApi/pkg/logger"
-	"errors"
 	"fmt"
+	"reflect"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// Lifetime of counted benefits, like free bets and spins,
+// given without explicit TimeDuration
+const DefaultBenefitLifetime = 30 * 24 * time.Hour
+
+// BenefitProgress is a benefit given to user and not used up yet.
+// Progress is expired by the expiry worker once ExpiresAt is reached,
+// ExpiryNotifiedAt is set when user was warned about expiry.
+// ExpiresAt is NULL for progresses given before benefits expired,
+// until it's set by the expiry worker.
+// ExpiresIn is seconds left before expiry, filled for the API.
 type BenefitProgress struct {
 	ID                             int64            `gorm:"primaryKey;autoIncrement"`
 	UserID                         int64            `gorm:"index"`
@@ -18,6 +29,136 @@ type BenefitProgress struct {
 	PolymorphicBenefitProgressID   int64            `gorm:"index"`
 	PolymorphicBenefitProgressType string           `gorm:"index"`
 	PolymorphicBenefitProgress     interface{}      `gorm:"-"`
+	ExpiresAt                      time.Time        `gorm:"index"`
+	ExpiryNotifiedAt               *time.Time
+	CreatedAt                      time.Time
+	ExpiresIn                      int64 `gorm:"-"`
+}
+
+// BenefitHandler implements polymorphic benefit type together with
+// its user progress. Each type registers its handler with
+// RegisterBenefitHandler from the file of its progress.
+type BenefitHandler interface {
+	// BenefitType returns PolymorphicBenefitType of the type.
+	BenefitType() string
+	// ProgressType returns PolymorphicBenefitProgressType of the type,
+	// empty for benefits applied at once.
+	ProgressType() string
+	// NewBenefit returns pointer to empty polymorphic benefit.
+	NewBenefit() interface{}
+	// NewProgress returns pointer to empty polymorphic benefit
+	// progress, nil for benefits applied at once.
+	NewProgress() interface{}
+	// Apply creates polymorphic benefit progress and linked
+	// BenefitProgress, or applies benefit at once. Benefit
+	// should contain existing polymorphic benefit.
+	Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error
+	// UseProgress spends one use of progress, which is pointer to loaded
+	// polymorphic progress. Returns true if progress is used up. Time
+	// limited progress is not spent and is used up once expired.
+	UseProgress(progress interface{}) (bool, error)
+}
+
+var (
+	benefitHandlers         = map[string]BenefitHandler{}
+	benefitProgressHandlers = map[string]BenefitHandler{}
+)
+
+// RegisterBenefitHandler registers benefit type handler, benefit
+// type is registered in benefits package as well. Panics if
+// type is already registered.
+func RegisterBenefitHandler(handler BenefitHandler) {
+	if _, ok := benefitHandlers[handler.BenefitType()]; ok {
+		panic(fmt.Sprintf("PolymorphicBenefitType %s already registered",
+			handler.BenefitType()))
+	}
+
+	benefits.RegisterBenefitType(handler.BenefitType(), handler.NewBenefit)
+	benefitHandlers[handler.BenefitType()] = handler
+	if handler.ProgressType() != "" {
+		benefitProgressHandlers[handler.ProgressType()] = handler
+	}
+}
+
+// GetBenefitHandler returns handler of polymorphic benefit type.
+func GetBenefitHandler(benefitType string) (BenefitHandler, error) {
+	handler, ok := benefitHandlers[benefitType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicBenefitType: %s", benefitType)
+	}
+
+	return handler, nil
+}
+
+func getBenefitProgressHandler(progressType string) (BenefitHandler, error) {
+	handler, ok := benefitProgressHandlers[progressType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicBenefitProgressType: %s", progressType)
+	}
+
+	return handler, nil
+}
+
+// benefitLifetime returns lifetime of benefit with given TimeDuration
+// in seconds, zero duration means DefaultBenefitLifetime.
+func benefitLifetime(timeDuration int64) time.Duration {
+	if timeDuration == 0 {
+		return DefaultBenefitLifetime
+	}
+	return time.Duration(timeDuration) * time.Second
+}
+
+// createBenefitProgress creates BenefitProgress linked to created
+// polymorphic benefit progress.
+func createBenefitProgress(tx *gorm.DB, userID, benefitID, progressID int64,
+	progressType string, expiresAt time.Time) error {
+	err := tx.Create(&BenefitProgress{
+		UserID:                         userID,
+		BenefitID:                      benefitID,
+		PolymorphicBenefitProgressID:   progressID,
+		PolymorphicBenefitProgressType: progressType,
+		ExpiresAt:                      expiresAt,
+	}).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// createRestoredBenefit creates benefit of given polymorphic benefit,
+// which is pointer to new polymorphic benefit. Used to give back used
+// up benefit progress, returned benefit contains polymorphic benefit.
+func createRestoredBenefit(tx *gorm.DB, polymorphicBenefit interface{}, benefitType string) (*benefits.Benefit, error) {
+	if err := tx.Create(polymorphicBenefit).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	benefit := benefits.Benefit{
+		PolymorphicBenefitID:   reflect.ValueOf(polymorphicBenefit).Elem().FieldByName("ID").Int(),
+		PolymorphicBenefitType: benefitType,
+	}
+	if err := tx.Create(&benefit).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	benefit.PolymorphicBenefit = reflect.ValueOf(polymorphicBenefit).Elem().Interface()
+
+	return &benefit, nil
+}
+
+// IsExpired reports whether progress is expired at given time.
+// Progress without ExpiresAt is not expired, it's set by the
+// expiry worker.
+func (bp *BenefitProgress) IsExpired(at time.Time) bool {
+	return !bp.ExpiresAt.IsZero() && !at.Before(bp.ExpiresAt)
+}
+
+// SetExpiresIn fills ExpiresIn with seconds left at given time.
+func (bp *BenefitProgress) SetExpiresIn(at time.Time) {
+	bp.ExpiresIn = 0
+	if !bp.ExpiresAt.IsZero() && !bp.IsExpired(at) {
+		bp.ExpiresIn = int64(bp.ExpiresAt.Sub(at).Seconds())
+	}
 }
 
 // PreloadPolymorphicBenefitProgress preloads BenefitProgress
@@ -27,102 +168,85 @@ func (bp *BenefitProgress) PreloadPolymorphicBenefitProgress(tx *gorm.DB) error
 		tx = db.DB
 	}
 
-	var err error
-	switch bp.PolymorphicBenefitProgressType {
-	case BenefitProgressBinaryOptionType:
-		var benBinOptProgress BenefitProgressBinaryOption
-		err = tx.First(&benBinOptProgress, bp.PolymorphicBenefitProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		bp.PolymorphicBenefitProgress = benBinOptProgress
-	case BenefitProgressClickerType:
-		var benClickerProgress BenefitProgressClicker
-		err = tx.First(&benClickerProgress, bp.PolymorphicBenefitProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		bp.PolymorphicBenefitProgress = benClickerProgress
-	case BenefitProgressFortuneWheelType:
-		var benFortWhProgress BenefitProgressFortuneWheel
-		err = tx.First(&benFortWhProgress, bp.PolymorphicBenefitProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		bp.PolymorphicBenefitProgress = benFortWhProgress
-	case BenefitProgressMiniGameType:
-		var benMiniGameProgress BenefitProgressMiniGame
-		err = tx.First(&benMiniGameProgress, bp.PolymorphicBenefitProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		bp.PolymorphicBenefitProgress = benMiniGameProgress
-	case BenefitProgressReplenishmentType:
-		var benReplProgress BenefitProgressReplenishment
-		err = tx.First(&benReplProgress, bp.PolymorphicBenefitProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		bp.PolymorphicBenefitProgress = benReplProgress
-	default:
-		return logger.WrapError(err, fmt.Sprintf(
-			"no such PolymorphicBenefitProgressType: %s",
-			bp.PolymorphicBenefitProgressType))
+	handler, err := getBenefitProgressHandler(bp.PolymorphicBenefitProgressType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	polymorphicBenefitProgress := handler.NewProgress()
+	err = tx.First(polymorphicBenefitProgress, bp.PolymorphicBenefitProgressID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
 	}
 
+	// Progresses are stored by value
+	bp.PolymorphicBenefitProgress = reflect.ValueOf(polymorphicBenefitProgress).Elem().Interface()
+
 	return nil
 }
 
 // CreateOrApplyPolymorphicBenefitProgress creates polymorphic benefit
 // progress and linked benefit progress. Benefit parameter should
 // contain existing polymorphic benefit.
-func CreateOrApplyPolymorphicBenefitProgress(tx *gorm.DB, benefit *benefits.Benefit, userID int64) (err error) {
-	switch benefit.PolymorphicBenefitType {
-	case benefits.BenefitBinaryOptionType:
-		if err = CreateBenefitProgressBinaryOption(
-			tx, benefit, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitClickerType:
-		if err = CreateOrApplyBenefitProgressClicker(
-			tx, benefit, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitCreditType:
-		benefitCredit, ok := benefit.PolymorphicBenefit.(benefits.BenefitCredit)
-		if !ok {
-			return logger.WrapError(errors.New(
-				"unable to cast benefit.PolymorphicBenefit to BenefitCredit"), "")
-		}
-		if err = benefitCredit.ApplyBenefit(tx, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitFortuneWheelType:
-		if err = CreateBenefitProgressFortuneWheel(
-			tx, benefit, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitItemType:
-		benefitItem, ok := benefit.PolymorphicBenefit.(benefits.BenefitItem)
-		if !ok {
-			return logger.WrapError(errors.New(
-				"unable to cast benefit.PolymorphicBenefit to BenefitItem"), "")
-		}
-		if err = benefitItem.ApplyBenefit(tx, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitMiniGameType:
-		if err = CreateBenefitProgressMiniGame(
-			tx, benefit, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	case benefits.BenefitReplenishmentType:
-		if err = CreateBenefitProgressReplenishment(
-			tx, benefit, userID); err != nil {
+func CreateOrApplyPolymorphicBenefitProgress(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	handler, err := GetBenefitHandler(benefit.PolymorphicBenefitType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = handler.Apply(tx, benefit, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// useBenefitProgress spends one use of loaded polymorphic progress of
+// benefit progress. Used up progress is deleted with BenefitProgress.
+func useBenefitProgress(tx *gorm.DB, bp *BenefitProgress, progress interface{}) error {
+	handler, err := getBenefitProgressHandler(bp.PolymorphicBenefitProgressType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	usedUp, err := handler.UseProgress(progress)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if usedUp {
+		if err = DeleteBenefitProgress(tx, bp); err != nil {
 			return logger.WrapError(err, "")
 		}
-	default:
-		return logger.WrapError(err, fmt.Sprintf("no such PolymorphicBenefitType: %s", benefit.PolymorphicBenefitType))
+		return nil
+	}
+
+	if err = tx.Save(progress).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// DeleteBenefitProgress deletes benefit progress with
+// its polymorphic benefit progress.
+func DeleteBenefitProgress(tx *gorm.DB, bp *BenefitProgress) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	handler, err := getBenefitProgressHandler(bp.PolymorphicBenefitProgressType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Delete(handler.NewProgress(), bp.PolymorphicBenefitProgressID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = tx.Delete(bp).Error; err != nil {
+		return logger.WrapError(err, "")
 	}
 
 	return nil
@@ -5,6 +5,7 @@ import (
 	"BlessedApi/internal/models/benefits"
 	"BlessedApi/pkg/logger"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +18,44 @@ type BenefitProgressBinaryOption struct {
 	FreeBetDepositRupee float64
 }
 
+type benefitBinaryOptionHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitBinaryOptionHandler{})
+}
+
+func (benefitBinaryOptionHandler) BenefitType() string {
+	return benefits.BenefitBinaryOptionType
+}
+
+func (benefitBinaryOptionHandler) ProgressType() string {
+	return BenefitProgressBinaryOptionType
+}
+
+func (benefitBinaryOptionHandler) NewBenefit() interface{} {
+	return &benefits.BenefitBinaryOption{}
+}
+
+func (benefitBinaryOptionHandler) NewProgress() interface{} {
+	return &BenefitProgressBinaryOption{}
+}
+
+func (benefitBinaryOptionHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	return CreateBenefitProgressBinaryOption(tx, benefit, userID)
+}
+
+func (benefitBinaryOptionHandler) UseProgress(progress interface{}) (bool, error) {
+	benefitProgressBinaryOption, ok := progress.(*BenefitProgressBinaryOption)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to cast progress to *BenefitProgressBinaryOption"), "")
+	}
+
+	benefitProgressBinaryOption.FreeBetsAmount--
+
+	return benefitProgressBinaryOption.FreeBetsAmount <= 0, nil
+}
+
 // CreateBenefitProgressBinaryOption creates BenefitProgressBinaryOption and
 // linked BenefitProgress. Benefit parameter should contain existing
 // polymorphic benefit.
@@ -42,11 +81,8 @@ func CreateBenefitProgressBinaryOption(tx *gorm.DB, benefit *benefits.Benefit, u
 		return logger.WrapError(err, "")
 	}
 
-	err = tx.Save(&BenefitProgress{
-		UserID:                         userID,
-		BenefitID:                      benefit.ID,
-		PolymorphicBenefitProgressID:   benefitProgressBinaryOption.ID,
-		PolymorphicBenefitProgressType: BenefitProgressBinaryOptionType}).Error
+	err = createBenefitProgress(tx, userID, benefit.ID, benefitProgressBinaryOption.ID,
+		BenefitProgressBinaryOptionType, time.Now().Add(benefitLifetime(benefitBinaryOpt.TimeDuration)))
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -66,8 +102,8 @@ func UseFreeBinaryOptionBetIfAvailable(tx *gorm.DB, userID int64) (
 
 	var benefitProgress BenefitProgress
 	err := tx.First(&benefitProgress,
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressBinaryOptionType).Error
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressBinaryOptionType, time.Now()).Error
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		// no available benefits
 		return 0, nil, nil
@@ -87,20 +123,7 @@ func UseFreeBinaryOptionBetIfAvailable(tx *gorm.DB, userID int64) (
 	}
 
 	return benefitProgressBinaryOption.FreeBetDepositRupee, func(tx *gorm.DB) error {
-		benefitProgressBinaryOption.FreeBetsAmount--
-
-		if benefitProgressBinaryOption.FreeBetsAmount == 0 {
-			if err = tx.Delete(&benefitProgressBinaryOption).Error; err != nil {
-				return logger.WrapError(err, "")
-			}
-
-			if err = tx.Delete(&benefitProgress).Error; err != nil {
-				return logger.WrapError(err, "")
-			}
-		} else if err = tx.Save(&benefitProgressBinaryOption).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
-		return nil
+		return useBenefitProgress(tx, &benefitProgress, &benefitProgressBinaryOption)
 	}, nil
 }
 
@@ -114,8 +137,8 @@ func GetUserFreeBinaryOptionBets(tx *gorm.DB, userID int64) (*[]BenefitProgressB
 
 	var benefitProgressIDs []int64
 	err := tx.Model(&BenefitProgress{}).Where(
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressBinaryOptionType).
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressBinaryOptionType, time.Now()).
 		Pluck("polymorphic_benefit_progress_id", &benefitProgressIDs).Error
 	if err != nil {
 		return nil, logger.WrapError(err, "")
@@ -133,3 +156,55 @@ func GetUserFreeBinaryOptionBets(tx *gorm.DB, userID int64) (*[]BenefitProgressB
 
 	return &benefitProgressBOs, nil
 }
+
+// RestoreFreeBinaryOptionBet gives back free bet of refunded binary
+// option bet. Bet is added to unexpired user progress with the same
+// free bet deposit, otherwise new single free bet benefit is given.
+func RestoreFreeBinaryOptionBet(tx *gorm.DB, userID int64, freeBetDepositRupee float64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var benefitProgresses []BenefitProgress
+	err := tx.Find(&benefitProgresses,
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressBinaryOptionType, time.Now()).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range benefitProgresses {
+		if err = benefitProgresses[i].PreloadPolymorphicBenefitProgress(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+		benefitProgressBinaryOption, ok := benefitProgresses[i].
+			PolymorphicBenefitProgress.(BenefitProgressBinaryOption)
+		if !ok {
+			return logger.WrapError(errors.New(
+				"unable to cast PolymorphicBenefitProgress to BenefitProgressBinaryOption"), "")
+		}
+
+		if benefitProgressBinaryOption.FreeBetDepositRupee == freeBetDepositRupee {
+			benefitProgressBinaryOption.FreeBetsAmount++
+			if err = tx.Save(&benefitProgressBinaryOption).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+			return nil
+		}
+	}
+
+	benefitBinaryOption := benefits.BenefitBinaryOption{
+		FreeBetsAmount:      1,
+		FreeBetDepositRupee: freeBetDepositRupee,
+	}
+	benefit, err := createRestoredBenefit(tx, &benefitBinaryOption, benefits.BenefitBinaryOptionType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = CreateBenefitProgressBinaryOption(tx, benefit, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
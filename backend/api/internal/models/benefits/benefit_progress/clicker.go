@@ -19,6 +19,42 @@ type BenefitProgressClicker struct {
 	BonusMultiplier float64
 }
 
+type benefitClickerHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitClickerHandler{})
+}
+
+func (benefitClickerHandler) BenefitType() string {
+	return benefits.BenefitClickerType
+}
+
+func (benefitClickerHandler) ProgressType() string {
+	return BenefitProgressClickerType
+}
+
+func (benefitClickerHandler) NewBenefit() interface{} {
+	return &benefits.BenefitClicker{}
+}
+
+func (benefitClickerHandler) NewProgress() interface{} {
+	return &BenefitProgressClicker{}
+}
+
+func (benefitClickerHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	return CreateOrApplyBenefitProgressClicker(tx, benefit, userID)
+}
+
+func (benefitClickerHandler) UseProgress(progress interface{}) (bool, error) {
+	benefitProgressClicker, ok := progress.(*BenefitProgressClicker)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to cast progress to *BenefitProgressClicker"), "")
+	}
+
+	return time.Now().After(benefitProgressClicker.ValidUntil), nil
+}
+
 // CreateOrApplyBenefitProgressClicker creates BenefitProgressClicker and
 // linked BenefitProgress. Benefit parameter should contain existing
 // polymorphic benefit.
@@ -53,11 +89,8 @@ func CreateOrApplyBenefitProgressClicker(tx *gorm.DB, benefit *benefits.Benefit,
 		return logger.WrapError(err, "")
 	}
 
-	err = tx.Save(&BenefitProgress{
-		UserID:                         userID,
-		BenefitID:                      benefit.ID,
-		PolymorphicBenefitProgressID:   benefitProgressClicker.ID,
-		PolymorphicBenefitProgressType: BenefitProgressClickerType}).Error
+	err = createBenefitProgress(tx, userID, benefit.ID, benefitProgressClicker.ID,
+		BenefitProgressClickerType, benefitProgressClicker.ValidUntil)
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -67,7 +100,7 @@ func CreateOrApplyBenefitProgressClicker(tx *gorm.DB, benefit *benefits.Benefit,
 
 // GetBenefitClickerProgressBonusMultiplier checks if there are available
 // clicker bonus and returns bcoins multiplier. If ValidUntil time reached,
-// BenefitProgressClicker with BenefitProgress will be deleted.
+// BenefitProgressClicker with BenefitProgress will be expired.
 func GetBenefitClickerProgressBonusMultiplier(tx *gorm.DB, UserID int64) (
 	bonusMultiplier float64, err error) {
 	if tx == nil {
@@ -101,11 +134,7 @@ func GetBenefitClickerProgressBonusMultiplier(tx *gorm.DB, UserID int64) (
 
 		// benefit expired
 		if time.Now().After(benefitProgressClicker.ValidUntil) {
-			if err = tx.Delete(benefitProgresses[i]).Error; err != nil {
-				return 1, logger.WrapError(err, "")
-			}
-
-			if err = tx.Delete(benefitProgressClicker).Error; err != nil {
+			if err = ExpireBenefitProgress(tx, &benefitProgresses[i]); err != nil {
 				return 1, logger.WrapError(err, "")
 			}
 		} else {
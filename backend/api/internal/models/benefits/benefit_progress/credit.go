@@ -0,0 +1,50 @@
+package benefit_progress
+
+import (
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// benefitCreditHandler applies BenefitCredit at once, without benefit progress.
+type benefitCreditHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitCreditHandler{})
+}
+
+func (benefitCreditHandler) BenefitType() string {
+	return benefits.BenefitCreditType
+}
+
+func (benefitCreditHandler) ProgressType() string {
+	return ""
+}
+
+func (benefitCreditHandler) NewBenefit() interface{} {
+	return &benefits.BenefitCredit{}
+}
+
+func (benefitCreditHandler) NewProgress() interface{} {
+	return nil
+}
+
+func (benefitCreditHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	benefitCredit, ok := benefit.PolymorphicBenefit.(benefits.BenefitCredit)
+	if !ok {
+		return logger.WrapError(errors.New(
+			"unable to cast benefit.PolymorphicBenefit to BenefitCredit"), "")
+	}
+
+	if err := benefitCredit.ApplyBenefit(tx, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+func (benefitCreditHandler) UseProgress(_ interface{}) (bool, error) {
+	return false, errors.New("benefit is applied at once and has no progress")
+}
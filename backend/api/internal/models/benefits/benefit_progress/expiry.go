@@ -0,0 +1,153 @@
+package benefit_progress
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpiredBenefitProgress records benefit progress removed on expiry.
+// Progress is a JSON snapshot of unused polymorphic progress.
+type ExpiredBenefitProgress struct {
+	ID                             int64  `gorm:"primaryKey;autoIncrement"`
+	UserID                         int64  `gorm:"index"`
+	BenefitID                      int64  `gorm:"index"`
+	PolymorphicBenefitProgressType string `gorm:"index"`
+	Progress                       string
+	ExpiresAt                      time.Time
+	ExpiredAt                      time.Time
+}
+
+// ExpireBenefitProgress records expiry of benefit progress and deletes
+// it with its polymorphic benefit progress.
+func ExpireBenefitProgress(tx *gorm.DB, bp *BenefitProgress) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if bp.PolymorphicBenefitProgress == nil {
+		if err := bp.PreloadPolymorphicBenefitProgress(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	progress, err := json.Marshal(bp.PolymorphicBenefitProgress)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Create(&ExpiredBenefitProgress{
+		UserID:                         bp.UserID,
+		BenefitID:                      bp.BenefitID,
+		PolymorphicBenefitProgressType: bp.PolymorphicBenefitProgressType,
+		Progress:                       string(progress),
+		ExpiresAt:                      bp.ExpiresAt,
+		ExpiredAt:                      time.Now(),
+	}).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = DeleteBenefitProgress(tx, bp); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// SetMissingBenefitProgressesExpiry sets ExpiresAt of benefit
+// progresses given before benefits expired. Timed benefits expire
+// when they are valid until, counted ones get the default lifetime
+// from now.
+func SetMissingBenefitProgressesExpiry(tx *gorm.DB) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	noExpiry := "(expires_at IS NULL OR expires_at = ?)"
+
+	timedProgresses := map[string]interface{}{
+		BenefitProgressClickerType:       &BenefitProgressClicker{},
+		BenefitProgressReplenishmentType: &BenefitProgressReplenishment{},
+	}
+	for progressType, progress := range timedProgresses {
+		validUntil := tx.Model(progress).Select("valid_until").
+			Where("id = benefit_progresses.polymorphic_benefit_progress_id")
+
+		err := tx.Model(&BenefitProgress{}).
+			Where("polymorphic_benefit_progress_type = ? AND "+noExpiry, progressType, time.Time{}).
+			Update("expires_at", gorm.Expr("(?)", validUntil)).Error
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	err := tx.Model(&BenefitProgress{}).
+		Where(noExpiry, time.Time{}).
+		Update("expires_at", time.Now().Add(DefaultBenefitLifetime)).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// GetExpiredBenefitProgresses returns up to limit benefit
+// progresses expired at given time, the oldest first.
+func GetExpiredBenefitProgresses(tx *gorm.DB, at time.Time, limit int) ([]BenefitProgress, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var benefitProgresses []BenefitProgress
+	err := tx.Where("expires_at <= ?", at).Order("expires_at").
+		Limit(limit).Find(&benefitProgresses).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return benefitProgresses, nil
+}
+
+// GetBenefitProgressesToNotify returns up to limit benefit progresses
+// expiring before given time, which users were not warned about.
+// Progresses given for less than minLifetime are skipped.
+func GetBenefitProgressesToNotify(tx *gorm.DB, now, before time.Time,
+	minLifetime time.Duration, limit int) ([]BenefitProgress, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var benefitProgresses []BenefitProgress
+	err := tx.Preload("Benefit").
+		Where("expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL", now, before).
+		// Progresses given before CreatedAt was recorded have long lifetime
+		Where("(created_at IS NULL OR EXTRACT(EPOCH FROM expires_at - created_at) >= ?)",
+			minLifetime.Seconds()).
+		Order("expires_at").Limit(limit).Find(&benefitProgresses).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return benefitProgresses, nil
+}
+
+// SetBenefitProgressExpiryNotified marks that user was warned
+// about benefit progress expiry.
+func SetBenefitProgressExpiryNotified(tx *gorm.DB, bp *BenefitProgress) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	now := time.Now()
+	err := tx.Model(bp).Update("expiry_notified_at", now).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	bp.ExpiryNotifiedAt = &now
+	return nil
+}
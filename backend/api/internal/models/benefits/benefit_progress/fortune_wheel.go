@@ -5,6 +5,7 @@ import (
 	"BlessedApi/internal/models/benefits"
 	"BlessedApi/pkg/logger"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,6 +17,44 @@ type BenefitProgressFortuneWheel struct {
 	FreeSpinsAmount int
 }
 
+type benefitFortuneWheelHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitFortuneWheelHandler{})
+}
+
+func (benefitFortuneWheelHandler) BenefitType() string {
+	return benefits.BenefitFortuneWheelType
+}
+
+func (benefitFortuneWheelHandler) ProgressType() string {
+	return BenefitProgressFortuneWheelType
+}
+
+func (benefitFortuneWheelHandler) NewBenefit() interface{} {
+	return &benefits.BenefitFortuneWheel{}
+}
+
+func (benefitFortuneWheelHandler) NewProgress() interface{} {
+	return &BenefitProgressFortuneWheel{}
+}
+
+func (benefitFortuneWheelHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	return CreateBenefitProgressFortuneWheel(tx, benefit, userID)
+}
+
+func (benefitFortuneWheelHandler) UseProgress(progress interface{}) (bool, error) {
+	benefitProgressFortuneWheel, ok := progress.(*BenefitProgressFortuneWheel)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to cast progress to *BenefitProgressFortuneWheel"), "")
+	}
+
+	benefitProgressFortuneWheel.FreeSpinsAmount--
+
+	return benefitProgressFortuneWheel.FreeSpinsAmount <= 0, nil
+}
+
 // CreateBenefitProgressFortuneWheel creates BenefitProgressFortuneWheel and
 // linked BenefitProgress. Benefit parameter should contain existing
 // polymorphic benefit.
@@ -40,11 +79,8 @@ func CreateBenefitProgressFortuneWheel(tx *gorm.DB, benefit *benefits.Benefit, u
 		return logger.WrapError(err, "")
 	}
 
-	err = tx.Save(&BenefitProgress{
-		UserID:                         userID,
-		BenefitID:                      benefit.ID,
-		PolymorphicBenefitProgressID:   benefitProgressFortuneWheel.ID,
-		PolymorphicBenefitProgressType: BenefitProgressFortuneWheelType}).Error
+	err = createBenefitProgress(tx, userID, benefit.ID, benefitProgressFortuneWheel.ID,
+		BenefitProgressFortuneWheelType, time.Now().Add(benefitLifetime(benefitFortuneWheel.TimeDuration)))
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -64,8 +100,8 @@ func UseFreeFortuneWheelSpinIfAvailable(tx *gorm.DB, userID int64) (
 
 	var benefitProgress BenefitProgress
 	err := tx.First(&benefitProgress,
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressFortuneWheelType).Error
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressFortuneWheelType, time.Now()).Error
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		// User dont have free spins
 		return false, nil, nil
@@ -85,22 +121,7 @@ func UseFreeFortuneWheelSpinIfAvailable(tx *gorm.DB, userID int64) (
 	}
 
 	return true, func(tx *gorm.DB) error {
-		benefitProgressFortuneWheel.FreeSpinsAmount--
-		if benefitProgressFortuneWheel.FreeSpinsAmount == 0 {
-			err = tx.Delete(&benefitProgressFortuneWheel).Error
-			if err != nil {
-				return logger.WrapError(err, "")
-			}
-
-			err = tx.Delete(&benefitProgress).Error
-			if err != nil {
-				return logger.WrapError(err, "")
-			}
-		} else if err = tx.Save(&benefitProgressFortuneWheel).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
-
-		return nil
+		return useBenefitProgress(tx, &benefitProgress, &benefitProgressFortuneWheel)
 	}, nil
 }
 
@@ -111,8 +132,8 @@ func GetUserAvailableSpins(tx *gorm.DB, userID int64) (int, error) {
 
 	var benefitProgresses []BenefitProgress
 	err := tx.Find(&benefitProgresses,
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressFortuneWheelType).Error
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressFortuneWheelType, time.Now()).Error
 	if err != nil {
 		return 0, logger.WrapError(err, "")
 	}
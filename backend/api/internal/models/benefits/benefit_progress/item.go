@@ -0,0 +1,50 @@
+package benefit_progress
+
+import (
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// benefitItemHandler applies BenefitItem at once, without benefit progress.
+type benefitItemHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitItemHandler{})
+}
+
+func (benefitItemHandler) BenefitType() string {
+	return benefits.BenefitItemType
+}
+
+func (benefitItemHandler) ProgressType() string {
+	return ""
+}
+
+func (benefitItemHandler) NewBenefit() interface{} {
+	return &benefits.BenefitItem{}
+}
+
+func (benefitItemHandler) NewProgress() interface{} {
+	return nil
+}
+
+func (benefitItemHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	benefitItem, ok := benefit.PolymorphicBenefit.(benefits.BenefitItem)
+	if !ok {
+		return logger.WrapError(errors.New(
+			"unable to cast benefit.PolymorphicBenefit to BenefitItem"), "")
+	}
+
+	if err := benefitItem.ApplyBenefit(tx, userID, benefit.ID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+func (benefitItemHandler) UseProgress(_ interface{}) (bool, error) {
+	return false, errors.New("benefit is applied at once and has no progress")
+}
@@ -5,6 +5,7 @@ import (
 	"BlessedApi/internal/models/benefits"
 	"BlessedApi/pkg/logger"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,6 +19,44 @@ type BenefitProgressMiniGame struct {
 	FreeBetDepositRupee float64
 }
 
+type benefitMiniGameHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitMiniGameHandler{})
+}
+
+func (benefitMiniGameHandler) BenefitType() string {
+	return benefits.BenefitMiniGameType
+}
+
+func (benefitMiniGameHandler) ProgressType() string {
+	return BenefitProgressMiniGameType
+}
+
+func (benefitMiniGameHandler) NewBenefit() interface{} {
+	return &benefits.BenefitMiniGame{}
+}
+
+func (benefitMiniGameHandler) NewProgress() interface{} {
+	return &BenefitProgressMiniGame{}
+}
+
+func (benefitMiniGameHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	return CreateBenefitProgressMiniGame(tx, benefit, userID)
+}
+
+func (benefitMiniGameHandler) UseProgress(progress interface{}) (bool, error) {
+	benefitProgressMiniGame, ok := progress.(*BenefitProgressMiniGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to cast progress to *BenefitProgressMiniGame"), "")
+	}
+
+	benefitProgressMiniGame.FreeBetsAmount--
+
+	return benefitProgressMiniGame.FreeBetsAmount <= 0, nil
+}
+
 // CreateBenefitProgressMiniGame creates BenefitProgressMiniGame and
 // linked BenefitProgress. Benefit parameter should contain existing
 // polymorphic benefit.
@@ -44,11 +83,8 @@ func CreateBenefitProgressMiniGame(tx *gorm.DB, benefit *benefits.Benefit, userI
 		return logger.WrapError(err, "")
 	}
 
-	err = tx.Save(&BenefitProgress{
-		UserID:                         userID,
-		BenefitID:                      benefit.ID,
-		PolymorphicBenefitProgressID:   BenefitProgressMiniGame.ID,
-		PolymorphicBenefitProgressType: BenefitProgressMiniGameType}).Error
+	err = createBenefitProgress(tx, userID, benefit.ID, BenefitProgressMiniGame.ID,
+		BenefitProgressMiniGameType, time.Now().Add(benefitLifetime(benefitMiniGame.TimeDuration)))
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -68,8 +104,8 @@ func UseFreeMiniGameBetIfAvailable(tx *gorm.DB, userID, gameID int64) (
 
 	var benefitProgresses []BenefitProgress
 	err := tx.Find(&benefitProgresses,
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressMiniGameType).Error
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressMiniGameType, time.Now()).Error
 	if err != nil {
 		return 0, nil, logger.WrapError(err, "")
 	}
@@ -93,19 +129,7 @@ func UseFreeMiniGameBetIfAvailable(tx *gorm.DB, userID, gameID int64) (
 		if benefitProgressMiniGame.GameID == gameID {
 
 			return benefitProgressMiniGame.FreeBetDepositRupee, func(tx *gorm.DB) error {
-				benefitProgressMiniGame.FreeBetsAmount--
-
-				if benefitProgressMiniGame.FreeBetsAmount == 0 {
-					if err = tx.Delete(&benefitProgressMiniGame).Error; err != nil {
-						return logger.WrapError(err, "")
-					}
-					if err = tx.Delete(&benefitProgresses[i]).Error; err != nil {
-						return logger.WrapError(err, "")
-					}
-				} else if err = tx.Save(&benefitProgressMiniGame).Error; err != nil {
-					return logger.WrapError(err, "")
-				}
-				return nil
+				return useBenefitProgress(tx, &benefitProgresses[i], &benefitProgressMiniGame)
 			}, nil
 		}
 	}
@@ -120,8 +144,8 @@ func GetUserFreeMiniGameBets(tx *gorm.DB, userID, gameID int64) (*[]BenefitProgr
 
 	var benefitProgressIDs []int64
 	err := tx.Model(&BenefitProgress{}).Where(
-		"user_id = ? and polymorphic_benefit_progress_type = ?",
-		userID, BenefitProgressMiniGameType).
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressMiniGameType, time.Now()).
 		Pluck("polymorphic_benefit_progress_id", &benefitProgressIDs).Error
 	if err != nil {
 		return nil, logger.WrapError(err, "")
@@ -141,3 +165,57 @@ func GetUserFreeMiniGameBets(tx *gorm.DB, userID, gameID int64) (*[]BenefitProgr
 
 	return &benefitProgressMGs, nil
 }
+
+// RestoreFreeMiniGameBet gives back free bet of refunded mini-game bet.
+// Bet is added to unexpired user progress with the same game and free
+// bet deposit, otherwise new single free bet benefit is given.
+func RestoreFreeMiniGameBet(tx *gorm.DB, userID, gameID int64, freeBetDepositRupee float64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var benefitProgresses []BenefitProgress
+	err := tx.Find(&benefitProgresses,
+		"user_id = ? and polymorphic_benefit_progress_type = ? and (expires_at IS NULL OR expires_at > ?)",
+		userID, BenefitProgressMiniGameType, time.Now()).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range benefitProgresses {
+		if err = benefitProgresses[i].PreloadPolymorphicBenefitProgress(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+		benefitProgressMiniGame, ok := benefitProgresses[i].
+			PolymorphicBenefitProgress.(BenefitProgressMiniGame)
+		if !ok {
+			return logger.WrapError(errors.New(
+				"unable to cast PolymorphicBenefitProgress to BenefitProgressMiniGame"), "")
+		}
+
+		if benefitProgressMiniGame.GameID == gameID &&
+			benefitProgressMiniGame.FreeBetDepositRupee == freeBetDepositRupee {
+			benefitProgressMiniGame.FreeBetsAmount++
+			if err = tx.Save(&benefitProgressMiniGame).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+			return nil
+		}
+	}
+
+	benefitMiniGame := benefits.BenefitMiniGame{
+		GameID:              gameID,
+		FreeBetsAmount:      1,
+		FreeBetDepositRupee: freeBetDepositRupee,
+	}
+	benefit, err := createRestoredBenefit(tx, &benefitMiniGame, benefits.BenefitMiniGameType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = CreateBenefitProgressMiniGame(tx, benefit, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
@@ -18,6 +18,42 @@ type BenefitProgressReplenishment struct {
 	BonusMultiplier float64
 }
 
+type benefitReplenishmentHandler struct{}
+
+func init() {
+	RegisterBenefitHandler(benefitReplenishmentHandler{})
+}
+
+func (benefitReplenishmentHandler) BenefitType() string {
+	return benefits.BenefitReplenishmentType
+}
+
+func (benefitReplenishmentHandler) ProgressType() string {
+	return BenefitProgressReplenishmentType
+}
+
+func (benefitReplenishmentHandler) NewBenefit() interface{} {
+	return &benefits.BenefitReplenishment{}
+}
+
+func (benefitReplenishmentHandler) NewProgress() interface{} {
+	return &BenefitProgressReplenishment{}
+}
+
+func (benefitReplenishmentHandler) Apply(tx *gorm.DB, benefit *benefits.Benefit, userID int64) error {
+	return CreateBenefitProgressReplenishment(tx, benefit, userID)
+}
+
+func (benefitReplenishmentHandler) UseProgress(progress interface{}) (bool, error) {
+	benefitProgressReplenishment, ok := progress.(*BenefitProgressReplenishment)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to cast progress to *BenefitProgressReplenishment"), "")
+	}
+
+	return time.Now().After(benefitProgressReplenishment.ValidUntil), nil
+}
+
 // CreateBenefitProgressReplenishment creates BenefitProgressReplenishment and
 // linked BenefitProgress. Benefit parameter should contain existing
 // polymorphic benefit.
@@ -44,11 +80,8 @@ func CreateBenefitProgressReplenishment(tx *gorm.DB, benefit *benefits.Benefit,
 		return logger.WrapError(err, "")
 	}
 
-	err = tx.Save(&BenefitProgress{
-		UserID:                         userID,
-		BenefitID:                      benefit.ID,
-		PolymorphicBenefitProgressID:   benefitProgressReplenishment.ID,
-		PolymorphicBenefitProgressType: BenefitProgressReplenishmentType}).Error
+	err = createBenefitProgress(tx, userID, benefit.ID, benefitProgressReplenishment.ID,
+		BenefitProgressReplenishmentType, benefitProgressReplenishment.ValidUntil)
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -58,7 +91,7 @@ func CreateBenefitProgressReplenishment(tx *gorm.DB, benefit *benefits.Benefit,
 
 // GetBenefitReplenishmentProgressBonusMultiplier checks if there are available
 // replenishment bonus and returns bcoins multiplier. If ValidUntil reached,
-// BenefitProgressReplenishment with BenefitProgress will be deleted.
+// BenefitProgressReplenishment with BenefitProgress will be expired.
 func GetBenefitReplenishmentProgressBonusMultiplier(tx *gorm.DB, UserID int64) (bonusMultiplier float64, err error) {
 	if tx == nil {
 		tx = db.DB
@@ -91,11 +124,7 @@ func GetBenefitReplenishmentProgressBonusMultiplier(tx *gorm.DB, UserID int64) (
 
 		// benefit expired
 		if time.Now().After(benefitProgressReplenishment.ValidUntil) {
-			if err = tx.Delete(benefitProgresses[i]).Error; err != nil {
-				return 1, logger.WrapError(err, "")
-			}
-
-			if err = tx.Delete(benefitProgressReplenishment).Error; err != nil {
+			if err = ExpireBenefitProgress(tx, &benefitProgresses[i]); err != nil {
 				return 1, logger.WrapError(err, "")
 			}
 		} else {
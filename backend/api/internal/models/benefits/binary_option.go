@@ -3,7 +3,9 @@ package benefits
 const BenefitBinaryOptionType = "benefit_binary_option"
 
 type BenefitBinaryOption struct {
-	ID                  int64 `gorm:"primaryKey;autoIncrement"`
-	FreeBetsAmount      int
-	FreeBetDepositRupee float64
+	ID                  int64   `gorm:"primaryKey;autoIncrement"`
+	FreeBetsAmount      int     `validate:"min=1"`
+	FreeBetDepositRupee float64 `validate:"gt=0"`
+	// Seconds the benefit is valid after given, 0 means default lifetime
+	TimeDuration int64 `validate:"min=0"`
 }
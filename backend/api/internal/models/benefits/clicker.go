@@ -3,8 +3,8 @@ package benefits
 const BenefitClickerType = "benefit_clicker"
 
 type BenefitClicker struct {
-	ID              int64 `gorm:"primaryKey;autoIncrement"`
-	TimeDuration    int64
-	BonusMultiplier float64
+	ID              int64   `gorm:"primaryKey;autoIncrement"`
+	TimeDuration    int64   `validate:"min=0"`
+	BonusMultiplier float64 `validate:"min=0"`
 	Reset           bool
 }
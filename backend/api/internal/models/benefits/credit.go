@@ -11,9 +11,9 @@ import (
 const BenefitCreditType = "benefit_credit"
 
 type BenefitCredit struct {
-	ID           int64 `gorm:"primaryKey;autoIncrement"`
-	BCoinsAmount float64
-	RupeeAmount  float64
+	ID           int64   `gorm:"primaryKey;autoIncrement"`
+	BCoinsAmount float64 `validate:"min=0"`
+	RupeeAmount  float64 `validate:"min=0"`
 }
 
 func (benefitCredit *BenefitCredit) ApplyBenefit(tx *gorm.DB, userID int64) error {
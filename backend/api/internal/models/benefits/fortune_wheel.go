@@ -4,5 +4,7 @@ const BenefitFortuneWheelType = "benefit_fortune_wheel"
 
 type BenefitFortuneWheel struct {
 	ID              int64 `gorm:"primaryKey;autoIncrement"`
-	FreeSpinsAmount int
+	FreeSpinsAmount int   `validate:"min=1"`
+	// Seconds the benefit is valid after given, 0 means default lifetime
+	TimeDuration int64 `validate:"min=0"`
 }
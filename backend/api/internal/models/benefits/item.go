@@ -1,32 +1,54 @@
 package benefits
 
 import (
-	"BlessedApi/internal/models"
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/items"
 	"BlessedApi/pkg/logger"
-	"fmt"
+	"errors"
 
 	"gorm.io/gorm"
 )
 
 const BenefitItemType = "benefit_item"
 
+// BenefitItem puts Quantity of catalogue items into user inventory.
 type BenefitItem struct {
-	ID       int64 `gorm:"primaryKey;autoIncrement"`
-	ItemName string
+	ID       int64       `gorm:"primaryKey;autoIncrement"`
+	ItemID   int64       `gorm:"index" validate:"required"`
+	Item     *items.Item `gorm:"foreignKey:ItemID" json:",omitempty"`
+	Quantity int64       `gorm:"not null;default:1" validate:"min=1"`
 }
 
-// todo: implement
-func (benefitItem *BenefitItem) ApplyBenefit(tx *gorm.DB, userID int64) error {
-	var user models.User
-	if err := tx.First(&user, userID).Error; err != nil {
-		return logger.WrapError(err, "Failed to fetch user")
+// ApplyBenefit puts items into user inventory, benefitID is
+// recorded as acquisition source. Missing or disabled item is not given.
+func (benefitItem *BenefitItem) ApplyBenefit(tx *gorm.DB, userID, benefitID int64) error {
+	if tx == nil {
+		tx = db.DB
 	}
 
-	// Apply the benefit (this is a placeholder, replace with actual benefit logic)
-	benefitDescription := fmt.Sprintf("Received benefit: %s", benefitItem.ItemName)
+	// Missing item must not fail the action the benefit is given for
+	item, err := items.GetItem(tx, benefitItem.ItemID)
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.BenefitItem("User with ID %d didn't receive missing item %d",
+			userID, benefitItem.ItemID)
+		return nil
+	} else if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if item.Disabled {
+		logger.BenefitItem("User with ID %d didn't receive disabled %s", userID, item.Name)
+		return nil
+	}
+
+	_, err = items.AddUserItem(tx, userID, item.ID, benefitItem.Quantity,
+		items.ItemSourceBenefit, benefitID)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
 
-	// Log the benefit application
-	logger.BenefitItem("User with ID %d (%s) %s", user.ID, user.Nickname, benefitDescription)
+	logger.BenefitItem("User with ID %d received %d x %s (%s)",
+		userID, benefitItem.Quantity, item.Name, item.Kind)
 
 	return nil
 }
@@ -3,8 +3,10 @@ package benefits
 const BenefitMiniGameType = "benefit_mini_game"
 
 type BenefitMiniGame struct {
-	ID                  int64 `gorm:"primaryKey;autoIncrement"`
-	GameID              int64 // gorm:"index"
-	FreeBetsAmount      int
-	FreeBetDepositRupee float64
+	ID                  int64   `gorm:"primaryKey;autoIncrement"`
+	GameID              int64   `validate:"oneof=1 2 3 4"` // gorm:"index"
+	FreeBetsAmount      int     `validate:"min=1"`
+	FreeBetDepositRupee float64 `validate:"gt=0"`
+	// Seconds the benefit is valid after given, 0 means default lifetime
+	TimeDuration int64 `validate:"min=0"`
 }
@@ -3,7 +3,7 @@ package benefits
 const BenefitReplenishmentType = "benefit_replenishment"
 
 type BenefitReplenishment struct {
-	ID              int64 `gorm:"primaryKey;autoIncrement"`
-	BonusMultiplier float64
-	TimeDuration    int64
+	ID              int64   `gorm:"primaryKey;autoIncrement"`
+	BonusMultiplier float64 `validate:"gt=0"`
+	TimeDuration    int64   `validate:"min=0"`
 }
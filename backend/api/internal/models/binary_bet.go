@@ -15,9 +15,24 @@ const (
 	BetDown BinaryBetDirection = "down"
 )
 
+// BinaryBet outcomes. Stake of push and refund bets is returned,
+// sold bet is closed before expiration at the quoted value.
+const (
+	BinaryBetWin    = "win"
+	BinaryBetLoss   = "loss"
+	BinaryBetPush   = "push"
+	BinaryBetRefund = "refund"
+	BinaryBetSold   = "sold"
+)
+
+// DefaultBinaryBetSymbol is symbol of bets placed before
+// instruments were introduced.
+const DefaultBinaryBetSymbol = "BTCUSDT"
+
 type BinaryBet struct {
 	ID               int64   `gorm:"primaryKey,autoIncrement"`
 	UserID           int64   `gorm:"not null;index"`
+	Symbol           string  `gorm:"not null;default:'BTCUSDT';index"`
 	Amount           float64 `gorm:"not null"`
 	FromBonusBalance float64 `json:"-"`
 	FromCashBalance  float64 `json:"-"`
@@ -29,10 +44,21 @@ type BinaryBet struct {
 	ClosePrice       float64
 	Outcome          string
 	Payout           float64
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	// Zero for bets placed before instruments were introduced
+	PayoutMultiplier float64
+	// Price moves in bet direction smaller than MinWinMove are a push
+	MinWinMove float64
+	// Settled is set together with Outcome by settlement scheduler,
+	// bets settled before scheduler have only Outcome set.
+	Settled             bool `gorm:"not null;default:false;index"`
+	SettledAt           *time.Time
+	SettlementLatencyMs int64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 }
 
+// UserHasActiveBet returns true if user has not settled bet,
+// including expired bet waiting for settlement.
 func UserHasActiveBet(tx *gorm.DB, userID int64) (bool, error) {
 	if tx == nil {
 		tx = db.DB
@@ -40,8 +66,7 @@ func UserHasActiveBet(tx *gorm.DB, userID int64) (bool, error) {
 
 	var count int64
 	err := tx.Model(&BinaryBet{}).
-		Where("user_id = ? AND expires_at > ?",
-			userID, time.Now()).
+		Where("user_id = ? AND settled = false AND outcome = ''", userID).
 		Count(&count).
 		Error
 	if err != nil {
@@ -50,31 +75,80 @@ func UserHasActiveBet(tx *gorm.DB, userID int64) (bool, error) {
 	return count > 0, nil
 }
 
-func MaintainLastTenBinaryOptionBets(tx *gorm.DB, userID int64) error {
+// GetDueBinaryBets returns not settled bets expired before now,
+// the oldest first.
+func GetDueBinaryBets(tx *gorm.DB, now time.Time, limit int) ([]BinaryBet, error) {
 	if tx == nil {
 		tx = db.DB
 	}
 
-	var count int64
-	if err := tx.Model(&BinaryBet{}).
-		Where("user_id = ?", userID).Count(&count).Error; err != nil {
-		return logger.WrapError(err, "")
+	var bets []BinaryBet
+	err := tx.Where("settled = false AND outcome = '' AND expires_at <= ?", now).
+		Order("expires_at asc").
+		Limit(limit).
+		Find(&bets).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
 	}
 
-	if count > 10 {
-		var oldestBets []BinaryBet
-		if err := tx.Where("user_id = ?", userID).
-			Order("created_at asc").Limit(int(count - 10)).
-			Find(&oldestBets).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
-
-		for _, bet := range oldestBets {
-			if err := tx.Delete(&bet).Error; err != nil {
-				return logger.WrapError(err, "")
-			}
-		}
+	return bets, nil
+}
+
+// MarkSettled sets settlement fields of the bet. Bet is not saved.
+func (bet *BinaryBet) MarkSettled(settledAt time.Time) {
+	bet.Settled = true
+	bet.SettledAt = &settledAt
+	bet.SettlementLatencyMs = settledAt.Sub(bet.ExpiresAt).Milliseconds()
+}
+
+// BinaryBetFilter filters user bets history, zero fields are not applied.
+// Outcome "pending" stands for not settled bets.
+type BinaryBetFilter struct {
+	Symbol    string
+	Outcome   string
+	Direction BinaryBetDirection
+	From      time.Time
+	To        time.Time
+}
+
+// GetUserBinaryBets returns page of user bets matching filter,
+// the newest first, and total number of matching bets.
+func GetUserBinaryBets(tx *gorm.DB, userID int64, filter BinaryBetFilter,
+	limit, offset int) ([]BinaryBet, int64, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	query := tx.Model(&BinaryBet{}).Where("user_id = ?", userID)
+	if filter.Symbol != "" {
+		query = query.Where("symbol = ?", filter.Symbol)
+	}
+	if filter.Outcome == "pending" {
+		query = query.Where("settled = false AND outcome = ''")
+	} else if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Direction != "" {
+		query = query.Where("direction = ?", filter.Direction)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("opened_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("opened_at <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, logger.WrapError(err, "")
+	}
+
+	bets := []BinaryBet{}
+	err := query.Order("created_at desc").Order("id desc").
+		Limit(limit).Offset(offset).Find(&bets).Error
+	if err != nil {
+		return nil, 0, logger.WrapError(err, "")
 	}
 
-	return nil
+	return bets, total, nil
 }
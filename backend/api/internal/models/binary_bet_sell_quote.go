@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BinaryBetSellQuote is a price offered to close BinaryBet
+// before expiration. Quote can be used once until ExpiresAt.
+type BinaryBetSellQuote struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"quoteID"`
+	BetID     int64     `gorm:"not null;index" json:"betID"`
+	UserID    int64     `gorm:"not null;index" json:"-"`
+	Price     float64   `gorm:"not null" json:"price"`
+	Value     float64   `gorm:"not null" json:"value"`
+	ExpiresAt time.Time `gorm:"not null" json:"validUntil"`
+	Used      bool      `gorm:"not null;default:false" json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CrashGameAutoBetAction string
+
+const (
+	CrashGameAutoBetReset    CrashGameAutoBetAction = "reset"
+	CrashGameAutoBetIncrease CrashGameAutoBetAction = "increase"
+)
+
+const (
+	CrashGameAutoBetActive  = "active"
+	CrashGameAutoBetStopped = "stopped"
+)
+
+// Reasons why CrashGameAutoBet plan was stopped
+const (
+	CrashGameAutoBetStopCancelled           = "cancelled"
+	CrashGameAutoBetStopProfitReached       = "profit_reached"
+	CrashGameAutoBetStopLossReached         = "loss_reached"
+	CrashGameAutoBetStopRoundsLimitReached  = "rounds_limit_reached"
+	CrashGameAutoBetStopInsufficientBalance = "insufficient_balance"
+	CrashGameAutoBetStopError               = "error"
+)
+
+// CrashGameAutoBet is a per user server-side plan, that places crash game
+// bet on each betting window until one of stop conditions is reached.
+// CurrentAmount is changed after every settled bet according to
+// OnWin/OnLoss actions: "reset" returns it to BaseAmount, "increase"
+// multiplies it by (1 + IncreasePercent/100). Zero StopOnProfit,
+// StopOnLoss and MaxRounds mean that condition is disabled.
+type CrashGameAutoBet struct {
+	ID                    int64                  `gorm:"primaryKey;autoIncrement"`
+	UserID                int64                  `gorm:"uniqueIndex;not null"`
+	BaseAmount            float64                `gorm:"not null"`
+	CurrentAmount         float64                `gorm:"not null"`
+	CashOutMultiplier     float64                `gorm:"not null"`
+	OnWinAction           CrashGameAutoBetAction `gorm:"not null;default:'reset'"`
+	OnWinIncreasePercent  float64
+	OnLossAction          CrashGameAutoBetAction `gorm:"not null;default:'reset'"`
+	OnLossIncreasePercent float64
+	StopOnProfit          float64
+	StopOnLoss            float64
+	MaxRounds             int
+	RoundsPlayed          int
+	NetProfit             float64
+	LastBetID             int64
+	Status                string `gorm:"index;not null"`
+	StopReason            string
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// ApplyLastBetResult loads last placed plan bet and moves plan progression
+// by its result. Returns false if last bet is still active and
+// plan should skip current round.
+func (ab *CrashGameAutoBet) ApplyLastBetResult(tx *gorm.DB) (bool, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if ab.LastBetID == 0 {
+		return true, nil
+	}
+
+	var bet CrashGameBet
+	err := tx.First(&bet, ab.LastBetID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		ab.LastBetID = 0
+		return true, nil
+	} else if err != nil {
+		return false, logger.WrapError(err, "")
+	}
+
+	switch bet.Status {
+	case "won":
+		ab.NetProfit += bet.WinAmount - bet.Amount
+		ab.CurrentAmount = ab.nextAmount(ab.OnWinAction, ab.OnWinIncreasePercent)
+	case "lost":
+		ab.NetProfit -= bet.Amount
+		ab.CurrentAmount = ab.nextAmount(ab.OnLossAction, ab.OnLossIncreasePercent)
+	default:
+		return false, nil
+	}
+
+	ab.LastBetID = 0
+	return true, nil
+}
+
+func (ab *CrashGameAutoBet) nextAmount(action CrashGameAutoBetAction, increasePercent float64) float64 {
+	if action == CrashGameAutoBetIncrease {
+		return math.Round(ab.CurrentAmount*(1+increasePercent/100)*100) / 100
+	}
+	return ab.BaseAmount
+}
+
+// StopReasonIfReached returns reason of plan stop or empty string
+// if plan should continue.
+func (ab *CrashGameAutoBet) StopReasonIfReached() string {
+	switch {
+	case ab.StopOnProfit > 0 && ab.NetProfit >= ab.StopOnProfit:
+		return CrashGameAutoBetStopProfitReached
+	case ab.StopOnLoss > 0 && -ab.NetProfit >= ab.StopOnLoss:
+		return CrashGameAutoBetStopLossReached
+	case ab.MaxRounds > 0 && ab.RoundsPlayed >= ab.MaxRounds:
+		return CrashGameAutoBetStopRoundsLimitReached
+	}
+	return ""
+}
+
+// Stop marks plan as stopped with given reason. Plan is not saved.
+func (ab *CrashGameAutoBet) Stop(reason string) {
+	ab.Status = CrashGameAutoBetStopped
+	ab.StopReason = reason
+}
+
+func GetActiveCrashGameAutoBets(tx *gorm.DB) ([]CrashGameAutoBet, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var autoBets []CrashGameAutoBet
+	err := tx.Find(&autoBets, "status = ?", CrashGameAutoBetActive).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return autoBets, nil
+}
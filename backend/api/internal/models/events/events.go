@@ -0,0 +1,89 @@
+package events
+
+import (
+	"BlessedApi/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Event is a domain event of user action. Events are published
+// inside the transaction of the action with Publish.
+type Event interface {
+	EventUserID() int64
+}
+
+// BetPlaced is published when bet stake is charged. GameID is one of
+// requirements game ids. Free bets are published with IsBenefitBet set.
+type BetPlaced struct {
+	UserID        int64
+	GameID        int64
+	AmountRupee   float64
+	FromCashRupee float64
+	IsBenefitBet  bool
+}
+
+// BetSettled is published when bet is paid out or lost. PayoutRupee
+// includes bonus balance payout, CashPayoutRupee is paid to cash
+// balance only. CashOutMultiplier is set for crash game cash-outs.
+// Refunded bets are not settled.
+type BetSettled struct {
+	UserID            int64
+	GameID            int64
+	AmountRupee       float64
+	FromCashRupee     float64
+	Won               bool
+	PayoutRupee       float64
+	CashPayoutRupee   float64
+	CashOutMultiplier float64
+	IsBenefitBet      bool
+}
+
+// DepositCredited is published when deposit is credited to cash
+// balance. AmountRupee doesn't include replenishment bonus.
+type DepositCredited struct {
+	UserID      int64
+	AmountRupee float64
+}
+
+// BcoinsExchanged is published when bcoins are exchanged to bonus balance.
+type BcoinsExchanged struct {
+	UserID       int64
+	AmountBcoins float64
+}
+
+// ClicksAdded is published when clicker clicks are counted.
+type ClicksAdded struct {
+	UserID      int64
+	ClicksCount int
+}
+
+func (e BetPlaced) EventUserID() int64       { return e.UserID }
+func (e BetSettled) EventUserID() int64      { return e.UserID }
+func (e DepositCredited) EventUserID() int64 { return e.UserID }
+func (e BcoinsExchanged) EventUserID() int64 { return e.UserID }
+func (e ClicksAdded) EventUserID() int64     { return e.UserID }
+
+// Subscriber handles published event inside the transaction
+// of the action. Returned error rolls the action back.
+type Subscriber func(tx *gorm.DB, event Event) error
+
+var subscribers []Subscriber
+
+// Subscribe adds subscriber for all events. Subscribers are
+// expected to be added from package init functions and are
+// called in order of subscription.
+func Subscribe(subscriber Subscriber) {
+	subscribers = append(subscribers, subscriber)
+}
+
+// Publish passes event to all subscribers. Should be called inside
+// the transaction of the action, tx must not be nil.
+func Publish(tx *gorm.DB, event Event) error {
+	for _, subscriber := range subscribers {
+		if err := subscriber(tx, event); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	return nil
+}
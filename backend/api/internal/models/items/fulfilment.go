@@ -0,0 +1,115 @@
+package items
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ItemFulfilmentStatus string
+
+const (
+	ItemFulfilmentPending   ItemFulfilmentStatus = "pending"
+	ItemFulfilmentShipped   ItemFulfilmentStatus = "shipped"
+	ItemFulfilmentDelivered ItemFulfilmentStatus = "delivered"
+	ItemFulfilmentRejected  ItemFulfilmentStatus = "rejected"
+)
+
+// ItemFulfilment is a request to deliver redeemed physical item.
+// Item of rejected request is returned to user inventory.
+type ItemFulfilment struct {
+	ID          int64                `gorm:"primaryKey;autoIncrement"`
+	UserID      int64                `gorm:"index;not null"`
+	ItemID      int64                `gorm:"index;not null"`
+	Item        Item                 `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE;"`
+	Status      ItemFulfilmentStatus `gorm:"index;not null"`
+	FullName    string               `gorm:"not null"`
+	PhoneNumber string               `gorm:"not null"`
+	Address     string               `gorm:"not null"`
+	Comment     string               `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsFinished reports whether request can't change status anymore.
+func (f *ItemFulfilment) IsFinished() bool {
+	return f.Status == ItemFulfilmentDelivered || f.Status == ItemFulfilmentRejected
+}
+
+// RedeemUserItem takes physical item from user inventory and creates
+// pending fulfilment request with given delivery details.
+func RedeemUserItem(tx *gorm.DB, userID, itemID int64, fulfilment *ItemFulfilment) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	userItem, err := getUserItemForUpdate(tx, userID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if userItem.Item.Kind != ItemKindPhysical {
+		return ErrItemNotRedeemable
+	}
+
+	userItem.Quantity--
+	if err = tx.Omit("Item").Save(userItem).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	fulfilment.UserID = userID
+	fulfilment.ItemID = itemID
+	fulfilment.Item = userItem.Item
+	fulfilment.Status = ItemFulfilmentPending
+	if err = tx.Omit("Item").Create(fulfilment).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	logger.BenefitItem("User with ID %d redeemed %s, fulfilment request %d",
+		userID, userItem.Item.Name, fulfilment.ID)
+
+	return nil
+}
+
+// UpdateItemFulfilmentStatus changes status of unfinished fulfilment
+// request. Rejected request returns the item to user inventory.
+func UpdateItemFulfilmentStatus(tx *gorm.DB, fulfilmentID int64,
+	status ItemFulfilmentStatus, comment string) (*ItemFulfilment, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var fulfilment ItemFulfilment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fulfilment, fulfilmentID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	if fulfilment.IsFinished() {
+		return nil, ErrFulfilmentFinished
+	}
+
+	fulfilment.Status = status
+	if comment != "" {
+		fulfilment.Comment = comment
+	}
+	if err = tx.Omit("Item").Save(&fulfilment).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	if status == ItemFulfilmentRejected {
+		_, err = AddUserItem(tx, fulfilment.UserID, fulfilment.ItemID, 1,
+			ItemSourceFulfilmentRejected, fulfilment.ID)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+	}
+
+	return &fulfilment, nil
+}
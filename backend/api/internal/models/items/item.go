@@ -0,0 +1,86 @@
+package items
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ItemKind string
+
+const (
+	// ItemKindBooster is consumed on use and applies its benefit
+	ItemKindBooster ItemKind = "booster"
+	// ItemKindCosmetic is equipped on use in its slot, never consumed
+	ItemKindCosmetic ItemKind = "cosmetic"
+	// ItemKindPhysical is redeemed with a fulfilment request
+	ItemKindPhysical ItemKind = "physical"
+)
+
+type CosmeticSlot string
+
+const (
+	CosmeticSlotAvatar CosmeticSlot = "avatar"
+	CosmeticSlotFrame  CosmeticSlot = "frame"
+)
+
+var (
+	ErrItemNotOwned       = errors.New("item is not in inventory")
+	ErrItemNotUsable      = errors.New("item can't be used")
+	ErrItemNotRedeemable  = errors.New("item can't be redeemed")
+	ErrFulfilmentFinished = errors.New("fulfilment request is already finished")
+)
+
+// Item is an entry of the item catalogue. BenefitID is the benefit
+// applied when booster is used, CosmeticSlot is set for cosmetics.
+// Disabled items are kept in user inventories but are not given anymore.
+type Item struct {
+	ID           int64        `gorm:"primaryKey;autoIncrement"`
+	Name         string       `gorm:"not null"`
+	Description  string       `gorm:"not null;default:''"`
+	ImageURL     string       `gorm:"not null;default:''"`
+	Kind         ItemKind     `gorm:"index;not null"`
+	CosmeticSlot CosmeticSlot `json:",omitempty"`
+	BenefitID    int64        `json:",omitempty"`
+	Disabled     bool         `gorm:"not null;default:false"`
+	CreatedAt    time.Time
+}
+
+// GetItem returns catalogue item, gorm.ErrRecordNotFound
+// if there is no such item.
+func GetItem(tx *gorm.DB, itemID int64) (*Item, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var item Item
+	err := tx.First(&item, itemID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &item, nil
+}
+
+// DisableItem stops giving item by benefits, item stays
+// in user inventories.
+func DisableItem(tx *gorm.DB, itemID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	result := tx.Model(&Item{}).Where("id = ?", itemID).Update("disabled", true)
+	if result.Error != nil {
+		return logger.WrapError(result.Error, "")
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package items
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ItemSource string
+
+const (
+	// Item is given by benefit, SourceID is the benefit id
+	ItemSourceBenefit ItemSource = "benefit"
+	// Item is returned by rejected fulfilment, SourceID is the fulfilment id
+	ItemSourceFulfilmentRejected ItemSource = "fulfilment_rejected"
+)
+
+// UserItem is an item in user inventory. Quantity is the count of items
+// left, used and redeemed items are subtracted. Equipped is set for
+// cosmetic item worn by user, one per cosmetic slot.
+type UserItem struct {
+	ID           int64                 `gorm:"primaryKey;autoIncrement"`
+	UserID       int64                 `gorm:"uniqueIndex:idx_user_item_user_item;not null"`
+	ItemID       int64                 `gorm:"uniqueIndex:idx_user_item_user_item;not null"`
+	Item         Item                  `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE;"`
+	Quantity     int64                 `gorm:"not null;default:0"`
+	Equipped     bool                  `gorm:"not null;default:false"`
+	Acquisitions []UserItemAcquisition `gorm:"foreignKey:UserItemID" json:",omitempty"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// UserItemAcquisition records how items came to user inventory.
+type UserItemAcquisition struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement"`
+	UserItemID int64      `gorm:"index;not null"`
+	Quantity   int64      `gorm:"not null"`
+	Source     ItemSource `gorm:"not null"`
+	SourceID   int64
+	CreatedAt  time.Time
+}
+
+// AddUserItem puts quantity of items into user inventory and records
+// the acquisition source.
+func AddUserItem(tx *gorm.DB, userID, itemID, quantity int64, source ItemSource, sourceID int64) (*UserItem, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "item_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("user_items.quantity + ?", quantity),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&UserItem{UserID: userID, ItemID: itemID, Quantity: quantity}).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var userItem UserItem
+	err = tx.Where("user_id = ? AND item_id = ?", userID, itemID).First(&userItem).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	err = tx.Create(&UserItemAcquisition{
+		UserItemID: userItem.ID,
+		Quantity:   quantity,
+		Source:     source,
+		SourceID:   sourceID,
+	}).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &userItem, nil
+}
+
+// GetUserItems returns user inventory with catalogue items and
+// acquisitions. Items with no quantity left are omitted.
+func GetUserItems(tx *gorm.DB, userID int64) ([]UserItem, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var userItems []UserItem
+	err := tx.Preload("Item").Preload("Acquisitions", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Where("user_id = ? AND quantity > 0", userID).Order("id").Find(&userItems).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return userItems, nil
+}
+
+// getUserItemForUpdate returns locked user inventory item with catalogue
+// item, ErrItemNotOwned if user has no such item left.
+func getUserItemForUpdate(tx *gorm.DB, userID, itemID int64) (*UserItem, error) {
+	var userItem UserItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND item_id = ? AND quantity > 0", userID, itemID).
+		First(&userItem).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrItemNotOwned
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	if err = tx.First(&userItem.Item, userItem.ItemID).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &userItem, nil
+}
+
+// UseUserItem uses item from user inventory. Booster is consumed, its
+// benefit should be applied by caller in the same transaction. Cosmetic
+// is equipped instead of other cosmetic of its slot. Physical items
+// are redeemed with RedeemUserItem.
+func UseUserItem(tx *gorm.DB, userID, itemID int64) (*UserItem, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	userItem, err := getUserItemForUpdate(tx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch userItem.Item.Kind {
+	case ItemKindBooster:
+		userItem.Quantity--
+	case ItemKindCosmetic:
+		err = tx.Model(&UserItem{}).
+			Where("user_id = ? AND equipped = ? AND item_id IN (?)", userID, true,
+				tx.Model(&Item{}).Select("id").Where("cosmetic_slot = ?", userItem.Item.CosmeticSlot)).
+			Update("equipped", false).Error
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+
+		userItem.Equipped = true
+	default:
+		return nil, ErrItemNotUsable
+	}
+
+	if err = tx.Omit("Item").Save(userItem).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return userItem, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// PriceCandle is OHLCV candle of Interval (e.g. "1m") aggregated
+// from archived PriceKline. OpenTime and CloseTime are unix milliseconds.
+type PriceCandle struct {
+	ID             int64   `gorm:"primaryKey;autoIncrement" json:"-"`
+	Symbol         string  `gorm:"not null;uniqueIndex:idx_price_candle_symbol_interval_open_time" json:"symbol"`
+	Interval       string  `gorm:"not null;uniqueIndex:idx_price_candle_symbol_interval_open_time" json:"interval"`
+	OpenTime       int64   `gorm:"not null;uniqueIndex:idx_price_candle_symbol_interval_open_time" json:"openTime"`
+	CloseTime      int64   `gorm:"not null" json:"closeTime"`
+	Open           float64 `gorm:"not null" json:"open"`
+	High           float64 `gorm:"not null" json:"high"`
+	Low            float64 `gorm:"not null" json:"low"`
+	Close          float64 `gorm:"not null" json:"close"`
+	Volume         float64 `json:"volume"`
+	NumberOfTrades int64   `json:"numberOfTrades"`
+}
+
+// AggregatePriceCandles recalculates symbol candles of the interval
+// lasting intervalMs, that contain klines opened from fromOpenTime
+// to toOpenTime.
+func AggregatePriceCandles(tx *gorm.DB, symbol, interval string, intervalMs, fromOpenTime, toOpenTime int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	// Whole candles are recalculated
+	fromOpenTime -= fromOpenTime % intervalMs
+	toOpenTime = toOpenTime - toOpenTime%intervalMs + intervalMs - 1
+
+	err := tx.Exec(`
+		INSERT INTO price_candles (symbol, "interval", open_time, close_time,
+			open, high, low, close, volume, number_of_trades)
+		SELECT symbol, ?, open_time - open_time % ?, open_time - open_time % ? + ? - 1,
+			(array_agg(open ORDER BY open_time ASC))[1], max(high), min(low),
+			(array_agg(close ORDER BY open_time DESC))[1], sum(volume), sum(number_of_trades)
+		FROM price_klines
+		WHERE symbol = ? AND open_time BETWEEN ? AND ?
+		GROUP BY symbol, open_time - open_time % ?
+		ON CONFLICT (symbol, "interval", open_time) DO UPDATE SET
+			close_time = EXCLUDED.close_time, open = EXCLUDED.open,
+			high = EXCLUDED.high, low = EXCLUDED.low, close = EXCLUDED.close,
+			volume = EXCLUDED.volume, number_of_trades = EXCLUDED.number_of_trades`,
+		interval, intervalMs, intervalMs, intervalMs,
+		symbol, fromOpenTime, toOpenTime, intervalMs).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// GetPriceCandles returns symbol candles of the interval opened
+// from fromOpenTime to toOpenTime inclusive, the oldest first.
+func GetPriceCandles(tx *gorm.DB, symbol, interval string, fromOpenTime, toOpenTime int64) ([]PriceCandle, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	candles := []PriceCandle{}
+	err := tx.Where(`symbol = ? AND "interval" = ? AND open_time BETWEEN ? AND ?`,
+		symbol, interval, fromOpenTime, toOpenTime).
+		Order("open_time asc").Find(&candles).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return candles, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceFeedGap is a period when symbol klines were not received.
+// EndedAt is nil while gap is ongoing.
+type PriceFeedGap struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	Symbol    string    `gorm:"not null;index"`
+	StartedAt time.Time `gorm:"not null"`
+	EndedAt   *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GetOpenPriceFeedGap returns ongoing gap of symbol or nil if there is none.
+func GetOpenPriceFeedGap(tx *gorm.DB, symbol string) (*PriceFeedGap, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var gap PriceFeedGap
+	err := tx.Where("symbol = ? AND ended_at IS NULL", symbol).
+		Order("started_at desc").First(&gap).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &gap, nil
+}
+
+// IsInPriceFeedGap returns true if moment t of symbol falls
+// inside ended or ongoing gap.
+func IsInPriceFeedGap(tx *gorm.DB, symbol string, t time.Time) (bool, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var count int64
+	err := tx.Model(&PriceFeedGap{}).
+		Where("symbol = ? AND started_at <= ? AND (ended_at IS NULL OR ended_at >= ?)", symbol, t, t).
+		Count(&count).Error
+	if err != nil {
+		return false, logger.WrapError(err, "")
+	}
+
+	return count > 0, nil
+}
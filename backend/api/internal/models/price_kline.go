@@ -0,0 +1,82 @@
+package models
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PriceKline is an archived 1s kline of binary options price feed.
+// OpenTime and CloseTime are unix milliseconds.
+type PriceKline struct {
+	ID             int64   `gorm:"primaryKey;autoIncrement" json:"-"`
+	Symbol         string  `gorm:"not null;uniqueIndex:idx_price_kline_symbol_open_time" json:"symbol"`
+	OpenTime       int64   `gorm:"not null;uniqueIndex:idx_price_kline_symbol_open_time" json:"openTime"`
+	CloseTime      int64   `gorm:"not null" json:"closeTime"`
+	Open           float64 `gorm:"not null" json:"open"`
+	High           float64 `gorm:"not null" json:"high"`
+	Low            float64 `gorm:"not null" json:"low"`
+	Close          float64 `gorm:"not null" json:"close"`
+	Volume         float64 `json:"volume"`
+	NumberOfTrades int64   `json:"numberOfTrades"`
+}
+
+// SavePriceKlines inserts klines, klines already archived are updated.
+func SavePriceKlines(tx *gorm.DB, klines []PriceKline) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if len(klines) == 0 {
+		return nil
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "symbol"}, {Name: "open_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"close_time", "open", "high", "low", "close", "volume", "number_of_trades"}),
+	}).CreateInBatches(&klines, 500).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// GetPriceKlines returns archived symbol klines opened from
+// fromOpenTime to toOpenTime inclusive, the oldest first.
+func GetPriceKlines(tx *gorm.DB, symbol string, fromOpenTime, toOpenTime int64) ([]PriceKline, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var klines []PriceKline
+	err := tx.Where("symbol = ? AND open_time BETWEEN ? AND ?", symbol, fromOpenTime, toOpenTime).
+		Order("open_time asc").Find(&klines).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return klines, nil
+}
+
+// GetLastPriceKlineOpenTime returns open time of the latest
+// archived symbol kline or 0 if there is none.
+func GetLastPriceKlineOpenTime(tx *gorm.DB, symbol string) (int64, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var kline PriceKline
+	err := tx.Where("symbol = ?", symbol).Order("open_time desc").First(&kline).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, logger.WrapError(err, "")
+	}
+
+	return kline.OpenTime, nil
+}
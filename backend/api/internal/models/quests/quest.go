@@ -0,0 +1,172 @@
+package quests
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type QuestPeriod string
+
+const (
+	QuestPeriodDaily  QuestPeriod = "daily"
+	QuestPeriodWeekly QuestPeriod = "weekly"
+)
+
+// Count of quests given to user in each period
+var questsPerPeriod = map[QuestPeriod]int{
+	QuestPeriodDaily:  3,
+	QuestPeriodWeekly: 2,
+}
+
+// Quest is a rotating daily or weekly task with single requirement.
+// Quest is in rotation for periods started between StartsAt and EndsAt,
+// nil bound is open, and after it was created until it was disabled.
+// Disabled quests are kept for given user quests.
+type Quest struct {
+	ID            int64                    `gorm:"primaryKey;autoIncrement"`
+	Name          string                   `gorm:"not null"`
+	Description   string                   `gorm:"not null;default:''"`
+	Period        QuestPeriod              `gorm:"index;not null"`
+	RequirementID int64                    `gorm:"index;not null"`
+	Requirement   requirements.Requirement `gorm:"foreignKey:RequirementID"`
+	Benefits      []QuestBenefit           `gorm:"foreignKey:QuestID"`
+	StartsAt      *time.Time
+	EndsAt        *time.Time
+	DisabledAt    *time.Time `gorm:"index"`
+	CreatedAt     time.Time
+}
+
+type QuestBenefit struct {
+	ID        int64            `gorm:"primaryKey;autoIncrement"`
+	QuestID   int64            `gorm:"index"`
+	BenefitID int64            `gorm:"index"`
+	Benefit   benefits.Benefit `gorm:"foreignKey:BenefitID;constraint:OnDelete:SET NULL;"`
+}
+
+// QuestPeriodBounds returns start and end of the quest period
+// containing given time. Days start at server midnight,
+// weeks start on Monday.
+func QuestPeriodBounds(period QuestPeriod, at time.Time) (time.Time, time.Time) {
+	startsAt := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	if period == QuestPeriodWeekly {
+		// Sunday is the last day of the week
+		weekday := (int(startsAt.Weekday()) + 6) % 7
+		startsAt = startsAt.AddDate(0, 0, -weekday)
+		return startsAt, startsAt.AddDate(0, 0, 7)
+	}
+
+	return startsAt, startsAt.AddDate(0, 0, 1)
+}
+
+// questPeriodIndex returns sequential number of the period
+// starting at periodStartsAt.
+func questPeriodIndex(period QuestPeriod, periodStartsAt time.Time) int64 {
+	days := periodStartsAt.Unix() / int64(24*time.Hour/time.Second)
+	if period == QuestPeriodWeekly {
+		return days / 7
+	}
+	return days
+}
+
+// GetScheduledQuests returns quests of the period starting at
+// periodStartsAt. Quests in rotation take turns in order of creation.
+// Quests created or disabled during the period don't change it,
+// so all users get the same quests in the period.
+func GetScheduledQuests(tx *gorm.DB, period QuestPeriod, periodStartsAt time.Time) ([]Quest, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var quests []Quest
+	err := tx.Where("period = ? AND created_at < ?", period, periodStartsAt).
+		Where("disabled_at IS NULL OR disabled_at > ?", periodStartsAt).
+		Where("starts_at IS NULL OR starts_at <= ?", periodStartsAt).
+		Where("ends_at IS NULL OR ends_at > ?", periodStartsAt).
+		Order("id").Find(&quests).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	count := questsPerPeriod[period]
+	if len(quests) <= count {
+		return quests, nil
+	}
+
+	offset := int(questPeriodIndex(period, periodStartsAt) * int64(count) % int64(len(quests)))
+	scheduled := make([]Quest, 0, count)
+	for i := 0; i < count; i++ {
+		scheduled = append(scheduled, quests[(offset+i)%len(quests)])
+	}
+
+	return scheduled, nil
+}
+
+// PreloadPolymorphics loads polymorphic requirement and benefits
+// of the quest. Requirement and Benefits should be preloaded.
+func (q *Quest) PreloadPolymorphics(tx *gorm.DB) error {
+	if err := q.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range q.Benefits {
+		if err := q.Benefits[i].Benefit.PreloadPolymorphicBenefit(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	return nil
+}
+
+// CreateQuest creates quest with existing requirement and benefits,
+// RequirementID of the quest should be set. Quest is in rotation from
+// the next period.
+func CreateQuest(tx *gorm.DB, quest *Quest, questBenefits []benefits.Benefit) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if err := tx.Omit("Requirement", "Benefits").Create(quest).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range questBenefits {
+		questBenefit := QuestBenefit{
+			QuestID:   quest.ID,
+			BenefitID: questBenefits[i].ID,
+			Benefit:   questBenefits[i],
+		}
+		if err := tx.Omit("Benefit").Create(&questBenefit).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		quest.Benefits = append(quest.Benefits, questBenefit)
+	}
+
+	return nil
+}
+
+// DisableQuest takes quest out of rotation from the next period.
+// Quest given to users in current periods stays until the period ends.
+func DisableQuest(tx *gorm.DB, questID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	// Quest disabled again keeps the first DisabledAt
+	result := tx.Model(&Quest{}).Where("id = ?", questID).
+		Update("disabled_at", gorm.Expr("COALESCE(disabled_at, ?)", time.Now()))
+	if result.Error != nil {
+		return logger.WrapError(result.Error, "")
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package quests
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/requirements/requirement_progress"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserQuest is a quest given to user for one period. Uncompleted
+// quest progress is reset when the period ends, ExpiredAt is set then.
+// RequirementProgress is loaded only for uncompleted quests of
+// the current period.
+type UserQuest struct {
+	ID                  int64       `gorm:"primaryKey;autoIncrement"`
+	UserID              int64       `gorm:"uniqueIndex:idx_user_quest_user_quest_period;not null"`
+	QuestID             int64       `gorm:"uniqueIndex:idx_user_quest_user_quest_period;not null"`
+	Quest               Quest       `gorm:"foreignKey:QuestID;constraint:OnDelete:CASCADE;"`
+	Period              QuestPeriod `gorm:"not null"`
+	PeriodStartsAt      time.Time   `gorm:"uniqueIndex:idx_user_quest_user_quest_period;not null"`
+	PeriodEndsAt        time.Time   `gorm:"index;not null"`
+	CompletedAt         *time.Time
+	ExpiredAt           *time.Time
+	RequirementProgress *requirement_progress.RequirementProgress `gorm:"-"`
+	CreatedAt           time.Time
+}
+
+func init() {
+	events.Subscribe(func(tx *gorm.DB, event events.Event) error {
+		_, err := UpdateUserQuests(tx, event)
+		return err
+	})
+}
+
+// EnsureUserQuests resets user quests of ended periods and gives
+// quests of current periods if not given yet. Quests added in the
+// middle of a period are given from the next period. Returns user
+// quests of current periods with Quest loaded.
+func EnsureUserQuests(tx *gorm.DB, userID int64) ([]UserQuest, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	// Concurrent actions of user should not give quests twice
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	now := time.Now()
+	if err = resetExpiredUserQuests(tx, userID, now); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	for _, period := range []QuestPeriod{QuestPeriodDaily, QuestPeriodWeekly} {
+		if err = giveUserQuests(tx, userID, period, now); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+	}
+
+	var userQuests []UserQuest
+	err = tx.Preload("Quest").
+		Where("user_id = ? AND period_starts_at <= ? AND period_ends_at > ?", userID, now, now).
+		Order("period, id").Find(&userQuests).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return userQuests, nil
+}
+
+// resetExpiredUserQuests deletes requirement progresses of uncompleted
+// user quests whose period ended before now.
+func resetExpiredUserQuests(tx *gorm.DB, userID int64, now time.Time) error {
+	var expired []UserQuest
+	err := tx.Preload("Quest").Find(&expired,
+		"user_id = ? AND completed_at IS NULL AND expired_at IS NULL AND period_ends_at <= ?",
+		userID, now).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+
+	userQuestIDs := make([]int64, 0, len(expired))
+	requirementIDs := make([]int64, 0, len(expired))
+	for _, userQuest := range expired {
+		userQuestIDs = append(userQuestIDs, userQuest.ID)
+		requirementIDs = append(requirementIDs, userQuest.Quest.RequirementID)
+	}
+
+	err = requirement_progress.DeleteUserRequirementProgresses(tx, userID, requirementIDs)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Model(&UserQuest{}).Where("id IN ?", userQuestIDs).
+		Update("expired_at", now).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// giveUserQuests gives user scheduled quests of the period containing
+// now, along with their requirement progresses.
+func giveUserQuests(tx *gorm.DB, userID int64, period QuestPeriod, now time.Time) error {
+	startsAt, endsAt := QuestPeriodBounds(period, now)
+
+	var given int64
+	err := tx.Model(&UserQuest{}).
+		Where("user_id = ? AND period = ? AND period_starts_at = ?", userID, period, startsAt).
+		Count(&given).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if given > 0 {
+		return nil
+	}
+
+	scheduled, err := GetScheduledQuests(tx, period, startsAt)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range scheduled {
+		userQuest := UserQuest{
+			UserID:         userID,
+			QuestID:        scheduled[i].ID,
+			Period:         period,
+			PeriodStartsAt: startsAt,
+			PeriodEndsAt:   endsAt,
+		}
+		if err = tx.Create(&userQuest).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		err = tx.Preload("Requirement").First(&scheduled[i], scheduled[i].ID).Error
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+		if err = scheduled[i].Requirement.PreloadPolymorphicRequirement(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+		if err = requirement_progress.CreatePolymorphicRequirementProgress(
+			tx, &scheduled[i].Requirement, userID); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	return nil
+}
+
+// UpdateUserQuests tracks event in requirement progresses of user quests
+// of current periods and gives benefits of completed quests. Returns
+// quests completed by the event.
+func UpdateUserQuests(tx *gorm.DB, event events.Event) ([]UserQuest, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	userID := event.EventUserID()
+
+	userQuests, err := EnsureUserQuests(tx, userID)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var requirementIDs []int64
+	for _, userQuest := range userQuests {
+		if userQuest.CompletedAt == nil {
+			requirementIDs = append(requirementIDs, userQuest.Quest.RequirementID)
+		}
+	}
+
+	done, err := requirement_progress.UpdateRequirementProgresses(tx, userID, requirementIDs, event)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if !done {
+		return nil, nil
+	}
+
+	var completedQuests []UserQuest
+	for i := range userQuests {
+		if userQuests[i].CompletedAt != nil {
+			continue
+		}
+
+		// Progress of completed requirement is removed
+		var reqProg requirement_progress.RequirementProgress
+		err = tx.First(&reqProg, "user_id = ? AND requirement_id = ?",
+			userID, userQuests[i].Quest.RequirementID).Error
+		if err == nil {
+			continue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, logger.WrapError(err, "")
+		}
+
+		if err = completeUserQuest(tx, &userQuests[i]); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+
+		completedQuests = append(completedQuests, userQuests[i])
+	}
+
+	for _, userQuest := range completedQuests {
+		logger.Info("User %d completed %s quest %d",
+			userID, userQuest.Period, userQuest.QuestID)
+	}
+
+	return completedQuests, nil
+}
+
+// completeUserQuest marks user quest completed and gives quest benefits.
+func completeUserQuest(tx *gorm.DB, userQuest *UserQuest) error {
+	now := time.Now()
+	userQuest.CompletedAt = &now
+	if err := tx.Model(userQuest).Update("completed_at", now).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var questBenefits []QuestBenefit
+	err := tx.Preload("Benefit").Find(&questBenefits, "quest_id = ?", userQuest.QuestID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range questBenefits {
+		if err = questBenefits[i].Benefit.PreloadPolymorphicBenefit(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+		if err = benefit_progress.CreateOrApplyPolymorphicBenefitProgress(
+			tx, &questBenefits[i].Benefit, userQuest.UserID); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	return nil
+}
+
+// PreloadRequirementProgress loads requirement progress of
+// uncompleted user quest, progress stays nil otherwise.
+func (uq *UserQuest) PreloadRequirementProgress(tx *gorm.DB) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if uq.CompletedAt != nil || uq.ExpiredAt != nil {
+		return nil
+	}
+
+	var reqProg requirement_progress.RequirementProgress
+	err := tx.First(&reqProg, "user_id = ? AND requirement_id = ?",
+		uq.UserID, uq.Quest.RequirementID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = reqProg.PreloadPolymorphicRequirementProgress(tx); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	uq.RequirementProgress = &reqProg
+	return nil
+}
+
+// GetCurrentUserQuests returns user quests of current periods with
+// polymorphic requirements, benefits and requirement progresses.
+func GetCurrentUserQuests(tx *gorm.DB, userID int64) ([]UserQuest, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	now := time.Now()
+
+	var userQuests []UserQuest
+	err := tx.Preload("Quest.Requirement").Preload("Quest.Benefits.Benefit").
+		Where("user_id = ? AND period_starts_at <= ? AND period_ends_at > ?", userID, now, now).
+		Order("period, id").Find(&userQuests).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	for i := range userQuests {
+		if err = userQuests[i].Quest.PreloadPolymorphics(tx); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		if err = userQuests[i].PreloadRequirementProgress(tx); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+	}
+
+	return userQuests, nil
+}
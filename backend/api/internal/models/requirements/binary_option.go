@@ -3,9 +3,9 @@ package requirements
 const RequirementBinaryOptionType = "requirement_binary_option"
 
 type RequirementBinaryOption struct {
-	ID                 int64 `gorm:"primaryKey;autoIncrement"`
-	MinBetRupee        float64
-	BetsAmount         int
-	WinsAmount         int
-	TotalWinningsRupee float64
+	ID                 int64   `gorm:"primaryKey;autoIncrement"`
+	MinBetRupee        float64 `validate:"min=0"`
+	BetsAmount         int     `validate:"min=0"`
+	WinsAmount         int     `validate:"min=0"`
+	TotalWinningsRupee float64 `validate:"min=0"`
 }
@@ -4,7 +4,7 @@ const RequirementClickerType = "requirement_clicker"
 
 type RequirementClicker struct {
 	ID           int64 `gorm:"primaryKey;autoIncrement"`
-	ClicksAmount int
-	TimeDuration int64
+	ClicksAmount int   `validate:"min=0"`
+	TimeDuration int64 `validate:"min=0"`
 	HitLimit     bool
 }
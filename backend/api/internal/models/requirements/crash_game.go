@@ -0,0 +1,14 @@
+package requirements
+
+const RequirementCrashGameType = "requirement_crash_game"
+
+// RequirementCrashGame counts crash game bets of at least MinBetRupee.
+// CashOutsAmount counts only cash-outs at CashOutMultiplier or higher.
+type RequirementCrashGame struct {
+	ID                 int64   `gorm:"primaryKey;autoIncrement"`
+	MinBetRupee        float64 `validate:"min=0"`
+	RoundsAmount       int     `validate:"min=0"`
+	CashOutMultiplier  float64 `validate:"min=0"`
+	CashOutsAmount     int     `validate:"min=0"`
+	TotalWinningsRupee float64 `validate:"min=0"`
+}
@@ -3,6 +3,6 @@ package requirements
 const RequirementExchangeType = "requirement_exchange"
 
 type RequirementExchange struct {
-	ID           int64 `gorm:"primaryKey;autoIncrement"`
-	BCoinsAmount float64
+	ID           int64   `gorm:"primaryKey;autoIncrement"`
+	BCoinsAmount float64 `validate:"gt=0"`
 }
@@ -6,12 +6,17 @@ const (
 	NvutiGameID    = 1
 	DiceGameID     = 2
 	RouletteGameID = 3
+	CrashGameID    = 4
+
+	// Binary options have no mini game requirements and
+	// benefits, id is used in bet events only
+	BinaryOptionGameID = 5
 )
 
 type RequirementMiniGame struct {
-	ID          int64 `gorm:"primaryKey;autoIncrement"`
-	GameID      int64 // gorm:"index"
-	MinBetRupee float64
-	BetsAmount  int
-	WinsAmount  int
+	ID          int64   `gorm:"primaryKey;autoIncrement"`
+	GameID      int64   `validate:"oneof=1 2 3"` // gorm:"index"
+	MinBetRupee float64 `validate:"min=0"`
+	BetsAmount  int     `validate:"min=0"`
+	WinsAmount  int     `validate:"min=0"`
 }
@@ -3,6 +3,6 @@ package requirements
 const RequirementReplenishmentType = "requirement_replenishment"
 
 type RequirementReplenishment struct {
-	ID          int64 `gorm:"primaryKey;autoIncrement"`
-	AmountRupee float64
+	ID          int64   `gorm:"primaryKey;autoIncrement"`
+	AmountRupee float64 `validate:"min=0"`
 }
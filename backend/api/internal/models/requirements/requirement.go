@@ -4,6 +4,7 @@ import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/pkg/logger"
 	"fmt"
+	"reflect"
 
 	"gorm.io/gorm"
 )
@@ -15,59 +16,52 @@ type Requirement struct {
 	PolymorphicRequirement     interface{} `gorm:"-"`
 }
 
+// polymorphicRequirementTypes maps PolymorphicRequirementType to
+// constructor of empty polymorphic requirement. Filled by
+// RegisterRequirementType.
+var polymorphicRequirementTypes = map[string]func() interface{}{}
+
+// RegisterRequirementType registers polymorphic requirement type.
+// newRequirement should return pointer to empty requirement of the type.
+// Types are registered with their progress handlers, see
+// requirement_progress.RegisterRequirementHandler. Panics if
+// type is already registered.
+func RegisterRequirementType(requirementType string, newRequirement func() interface{}) {
+	if _, ok := polymorphicRequirementTypes[requirementType]; ok {
+		panic(fmt.Sprintf("PolymorphicRequirementType %s already registered", requirementType))
+	}
+	polymorphicRequirementTypes[requirementType] = newRequirement
+}
+
 // Loads requirement from db by its type and id. Errors not for export
 func (req *Requirement) PreloadPolymorphicRequirement(tx *gorm.DB) error {
 	if tx == nil {
 		tx = db.DB
 	}
 
-	var err error
-	switch req.PolymorphicRequirementType {
-	case RequirementMiniGameType:
-		var requirementMiniGame RequirementMiniGame
-		err = tx.First(&requirementMiniGame, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementMiniGame
-	case RequirementClickerType:
-		var requirementClicker RequirementClicker
-		err = tx.First(&requirementClicker, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementClicker
-	case RequirementExchangeType:
-		var requirementExchange RequirementExchange
-		err = tx.First(&requirementExchange, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementExchange
-	case RequirementReplenishmentType:
-		var requirementReplenishment RequirementReplenishment
-		err = tx.First(&requirementReplenishment, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementReplenishment
-	case RequirementTurnoverType:
-		var requirementTurnover RequirementTurnover
-		err = tx.First(&requirementTurnover, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementTurnover
-	case RequirementBinaryOptionType:
-		var requirementBinaryOption RequirementBinaryOption
-		err = tx.First(&requirementBinaryOption, req.PolymorphicRequirementID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-		req.PolymorphicRequirement = requirementBinaryOption
-	default:
-		return logger.WrapError(err, fmt.Sprintf("no such PolymorphicRequirementType: %s", req.PolymorphicRequirementType))
+	polymorphicRequirement, err := NewPolymorphicRequirement(req.PolymorphicRequirementType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.First(polymorphicRequirement, req.PolymorphicRequirementID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
 	}
 
+	// Requirements are stored by value
+	req.PolymorphicRequirement = reflect.ValueOf(polymorphicRequirement).Elem().Interface()
+
 	return nil
 }
+
+// NewPolymorphicRequirement returns pointer to empty
+// polymorphic requirement of given type.
+func NewPolymorphicRequirement(requirementType string) (interface{}, error) {
+	newRequirement, ok := polymorphicRequirementTypes[requirementType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicRequirementType: %s", requirementType)
+	}
+
+	return newRequirement(), nil
+}
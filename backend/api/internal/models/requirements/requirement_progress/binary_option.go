@@ -2,6 +2,7 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -51,76 +52,93 @@ func CreateRequirementProgressBinaryOption(tx *gorm.DB, requirement *requirement
 	return nil
 }
 
-// UpdateRequirementProgressBinaryOptionIfRequired returns true if
-// requirement completed and removes RequirementProgressBinaryOption with
-// RequirementProgress.
-func UpdateRequirementProgressBinaryOptionIfRequired(
-	tx *gorm.DB, userID int64, betAmount, betPayout float64) (bool, error) {
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementBinaryOptionHandler struct{}
 
-	// check if requirement set
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?", userID, RequirementProgressBinaryOptionType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementBinaryOptionHandler{})
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
-	}
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementBinaryOptionHandler) RequirementType() string {
+	return requirements.RequirementBinaryOptionType
+}
+
+func (requirementBinaryOptionHandler) ProgressType() string {
+	return RequirementProgressBinaryOptionType
+}
+
+func (requirementBinaryOptionHandler) NewRequirement() interface{} {
+	return &requirements.RequirementBinaryOption{}
+}
+
+func (requirementBinaryOptionHandler) NewProgress() interface{} {
+	return &RequirementProgressBinaryOption{}
+}
+
+func (requirementBinaryOptionHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressBinaryOption(tx, requirement, userID)
+}
+
+func (requirementBinaryOptionHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	bet, ok := event.(events.BetSettled)
+	if !ok || bet.IsBenefitBet || bet.GameID != requirements.BinaryOptionGameID {
+		return false, nil
 	}
 
-	requirementProgressBinaryOption, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressBinaryOption)
+	requirementProgressBinaryOption, ok := progress.(*RequirementProgressBinaryOption)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressBinaryOption)"), "")
+			"unable to convert progress to (*RequirementProgressBinaryOption)"), "")
 	}
-	requirementBinaryOption, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementBinaryOption)
+	requirementBinaryOption, ok := requirement.(requirements.RequirementBinaryOption)
 	if !ok {
 		return false, logger.WrapError(errors.New(
 			"unable to convert PolymorphicRequirement to (RequirementBinaryOption)"), "")
 	}
 
-	if betAmount <
-		requirementBinaryOption.MinBetRupee {
+	if bet.FromCashRupee < requirementBinaryOption.MinBetRupee {
 		return false, nil
 	}
 
 	requirementProgressBinaryOption.BetsAmount++
-	if betPayout > 0 {
+	if bet.Won {
 		requirementProgressBinaryOption.WinsAmount++
-		requirementProgressBinaryOption.TotalWinningsRupee += betPayout
+		requirementProgressBinaryOption.TotalWinningsRupee += bet.CashPayoutRupee
+	}
+
+	return requirementProgressBinaryOption.completes(requirementBinaryOption), nil
+}
+
+func (requirementBinaryOptionHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressBinaryOption, ok := progress.(*RequirementProgressBinaryOption)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressBinaryOption)"), "")
+	}
+	previousBinaryOption, ok := previous.(requirements.RequirementBinaryOption)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert previous PolymorphicRequirement to (RequirementBinaryOption)"), "")
+	}
+	requirementBinaryOption, ok := requirement.(requirements.RequirementBinaryOption)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementBinaryOption)"), "")
 	}
 
-	if requirementProgressBinaryOption.BetsAmount >=
-		requirementBinaryOption.BetsAmount &&
-		requirementProgressBinaryOption.WinsAmount >=
-			requirementBinaryOption.WinsAmount &&
-		requirementProgressBinaryOption.TotalWinningsRupee >=
-			requirementBinaryOption.TotalWinningsRupee {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressBinaryOption).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		return true, nil
-	} else if err = tx.Save(&requirementProgressBinaryOption).Error; err != nil {
-		return false, logger.WrapError(err, "")
+	// Smaller bets don't count anymore
+	if previousBinaryOption.MinBetRupee != requirementBinaryOption.MinBetRupee {
+		*requirementProgressBinaryOption = RequirementProgressBinaryOption{
+			ID: requirementProgressBinaryOption.ID}
 	}
 
-	return false, nil
+	return requirementProgressBinaryOption.completes(requirementBinaryOption), nil
+}
+
+func (p *RequirementProgressBinaryOption) completes(requirement requirements.RequirementBinaryOption) bool {
+	return p.BetsAmount >= requirement.BetsAmount &&
+		p.WinsAmount >= requirement.WinsAmount &&
+		p.TotalWinningsRupee >= requirement.TotalWinningsRupee
 }
@@ -3,6 +3,7 @@ package requirement_progress
 import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -53,40 +54,46 @@ func CreateRequirementProgressClicker(tx *gorm.DB, requirement *requirements.Req
 	return nil
 }
 
-// UpdateRequirementProgressClickerIfRequired returns true if
-// requirement completed and removes RequirementProgressClicker with
-// RequirementProgress.
-func UpdateRequirementProgressClickerIfRequired(tx *gorm.DB, userID int64, clicksCount int) (bool, error) {
-	// check if requirement set
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementClickerHandler struct{}
 
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?", userID, RequirementProgressClickerType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementClickerHandler{})
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
-	}
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementClickerHandler) RequirementType() string {
+	return requirements.RequirementClickerType
+}
+
+func (requirementClickerHandler) ProgressType() string {
+	return RequirementProgressClickerType
+}
+
+func (requirementClickerHandler) NewRequirement() interface{} {
+	return &requirements.RequirementClicker{}
+}
+
+func (requirementClickerHandler) NewProgress() interface{} {
+	return &RequirementProgressClicker{}
+}
+
+func (requirementClickerHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressClicker(tx, requirement, userID)
+}
+
+func (requirementClickerHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	clicks, ok := event.(events.ClicksAdded)
+	if !ok {
+		return false, nil
 	}
 
-	requirementProgressClicker, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressClicker)
+	requirementProgressClicker, ok := progress.(*RequirementProgressClicker)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressClicker)"), "")
+			"unable to convert progress to (*RequirementProgressClicker)"), "")
 	}
-	requirementClicker, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementClicker)
+	requirementClicker, ok := requirement.(requirements.RequirementClicker)
 	if !ok {
 		return false, logger.WrapError(errors.New(
 			"unable to convert PolymorphicRequirement to (RequirementClicker)"), "")
@@ -94,22 +101,12 @@ func UpdateRequirementProgressClickerIfRequired(tx *gorm.DB, userID int64, click
 
 	// if requirement is hit daily clicks limit
 	if requirementClicker.HitLimit {
-		var userDailyClicks int
-		err := tx.Model(&models.User{}).Where("id = ?", userID).Pluck("daily_clicks", &userDailyClicks).Error
+		hitLimit, err := userHitDailyClicksLimit(tx, userID)
 		if err != nil {
 			return false, logger.WrapError(err, "")
 		}
 
-		if userDailyClicks == models.DailyClicksLimit {
-			// requirement completed
-			if err = tx.Delete(&requirementProgressClicker).Error; err != nil {
-				return false, logger.WrapError(err, "")
-			}
-
-			if err = tx.Delete(&requirementProgress).Error; err != nil {
-				return false, logger.WrapError(err, "")
-			}
-
+		if hitLimit {
 			return true, nil
 		}
 	}
@@ -119,26 +116,54 @@ func UpdateRequirementProgressClickerIfRequired(tx *gorm.DB, userID int64, click
 
 	// User progress expired
 	if timeDuration != 0 && startedAt.Add(timeDuration).Before(time.Now()) {
-		requirementProgressClicker.CurrentClicks = clicksCount
+		requirementProgressClicker.CurrentClicks = clicks.ClicksCount
 		requirementProgressClicker.StartedAt = time.Now()
 	} else {
-		requirementProgressClicker.CurrentClicks += clicksCount
+		requirementProgressClicker.CurrentClicks += clicks.ClicksCount
+	}
+
+	return requirementProgressClicker.CurrentClicks >= requirementClicker.ClicksAmount, nil
+}
+
+func (requirementClickerHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressClicker, ok := progress.(*RequirementProgressClicker)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressClicker)"), "")
+	}
+	requirementClicker, ok := requirement.(requirements.RequirementClicker)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementClicker)"), "")
 	}
 
-	if requirementProgressClicker.CurrentClicks >= requirementClicker.ClicksAmount {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressClicker).Error; err != nil {
+	if requirementClicker.HitLimit {
+		hitLimit, err := userHitDailyClicksLimit(tx, userID)
+		if err != nil {
 			return false, logger.WrapError(err, "")
 		}
 
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
+		if hitLimit {
+			return true, nil
 		}
+	}
 
-		return true, nil
-	} else if err = tx.Save(&requirementProgressClicker).Error; err != nil {
+	// Expired progress starts over with the next clicks
+	timeDuration := time.Duration(requirementClicker.TimeDuration) * time.Second
+	if timeDuration != 0 && requirementProgressClicker.StartedAt.Add(timeDuration).Before(time.Now()) {
+		return false, nil
+	}
+
+	return requirementProgressClicker.CurrentClicks >= requirementClicker.ClicksAmount, nil
+}
+
+func userHitDailyClicksLimit(tx *gorm.DB, userID int64) (bool, error) {
+	var userDailyClicks int
+	err := tx.Model(&models.User{}).Where("id = ?", userID).Pluck("daily_clicks", &userDailyClicks).Error
+	if err != nil {
 		return false, logger.WrapError(err, "")
 	}
 
-	return false, nil
+	return userDailyClicks == models.DailyClicksLimit, nil
 }
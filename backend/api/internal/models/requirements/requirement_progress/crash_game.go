@@ -0,0 +1,151 @@
+package requirement_progress
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const RequirementProgressCrashGameType = "requirement_progress_crash_game"
+
+type RequirementProgressCrashGame struct {
+	ID                 int64 `gorm:"primaryKey;autoIncrement"`
+	RoundsAmount       int
+	CashOutsAmount     int
+	TotalWinningsRupee float64
+}
+
+// CreateRequirementProgressCrashGame creates RequirementProgressCrashGame and
+// linked RequirementProgress. Requirement parameter should contain existing
+// polymorphic requirement.
+func CreateRequirementProgressCrashGame(tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	crashGameReqProgress := RequirementProgressCrashGame{}
+
+	var requirementProgress RequirementProgress
+
+	err := tx.Save(&crashGameReqProgress).Scan(&crashGameReqProgress).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	requirementProgress = RequirementProgress{
+		UserID:                             userID,
+		RequirementID:                      requirement.ID,
+		PolymorphicRequirementProgressID:   crashGameReqProgress.ID,
+		PolymorphicRequirementProgressType: RequirementProgressCrashGameType,
+		PolymorphicRequirementProgress:     crashGameReqProgress,
+	}
+
+	err = tx.Save(&requirementProgress).Scan(&requirementProgress).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+type requirementCrashGameHandler struct{}
+
+func init() {
+	RegisterRequirementHandler(requirementCrashGameHandler{})
+}
+
+func (requirementCrashGameHandler) RequirementType() string {
+	return requirements.RequirementCrashGameType
+}
+
+func (requirementCrashGameHandler) ProgressType() string {
+	return RequirementProgressCrashGameType
+}
+
+func (requirementCrashGameHandler) NewRequirement() interface{} {
+	return &requirements.RequirementCrashGame{}
+}
+
+func (requirementCrashGameHandler) NewProgress() interface{} {
+	return &RequirementProgressCrashGame{}
+}
+
+func (requirementCrashGameHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressCrashGame(tx, requirement, userID)
+}
+
+func (requirementCrashGameHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	requirementProgressCrashGame, ok := progress.(*RequirementProgressCrashGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressCrashGame)"), "")
+	}
+	requirementCrashGame, ok := requirement.(requirements.RequirementCrashGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementCrashGame)"), "")
+	}
+
+	// Placed bet counts as a played round, cashed out
+	// bet counts with its multiplier and payout
+	switch bet := event.(type) {
+	case events.BetPlaced:
+		if bet.IsBenefitBet || bet.GameID != requirements.CrashGameID ||
+			bet.FromCashRupee < requirementCrashGame.MinBetRupee {
+			return false, nil
+		}
+		requirementProgressCrashGame.RoundsAmount++
+	case events.BetSettled:
+		if bet.IsBenefitBet || bet.GameID != requirements.CrashGameID ||
+			bet.FromCashRupee < requirementCrashGame.MinBetRupee || !bet.Won {
+			return false, nil
+		}
+		if bet.CashOutMultiplier >= requirementCrashGame.CashOutMultiplier {
+			requirementProgressCrashGame.CashOutsAmount++
+		}
+		requirementProgressCrashGame.TotalWinningsRupee += bet.CashPayoutRupee
+	default:
+		return false, nil
+	}
+
+	return requirementProgressCrashGame.completes(requirementCrashGame), nil
+}
+
+func (requirementCrashGameHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressCrashGame, ok := progress.(*RequirementProgressCrashGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressCrashGame)"), "")
+	}
+	previousCrashGame, ok := previous.(requirements.RequirementCrashGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert previous PolymorphicRequirement to (RequirementCrashGame)"), "")
+	}
+	requirementCrashGame, ok := requirement.(requirements.RequirementCrashGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementCrashGame)"), "")
+	}
+
+	// Smaller bets or cash-outs at lower multiplier don't count anymore
+	if previousCrashGame.MinBetRupee != requirementCrashGame.MinBetRupee ||
+		previousCrashGame.CashOutMultiplier != requirementCrashGame.CashOutMultiplier {
+		*requirementProgressCrashGame = RequirementProgressCrashGame{ID: requirementProgressCrashGame.ID}
+	}
+
+	return requirementProgressCrashGame.completes(requirementCrashGame), nil
+}
+
+func (p *RequirementProgressCrashGame) completes(requirement requirements.RequirementCrashGame) bool {
+	return p.RoundsAmount >= requirement.RoundsAmount &&
+		p.CashOutsAmount >= requirement.CashOutsAmount &&
+		p.TotalWinningsRupee >= requirement.TotalWinningsRupee
+}
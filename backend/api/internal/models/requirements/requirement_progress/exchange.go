@@ -2,6 +2,7 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -49,62 +50,70 @@ func CreateRequirementProgressExchange(tx *gorm.DB, requirement *requirements.Re
 	return nil
 }
 
-// UpdateRequirementProgressExchangeIfRequired returns true if
-// requirement completed and removes RequirementProgressExchange with
-// RequirementProgress.
-func UpdateRequirementProgressExchangeIfRequired(
-	tx *gorm.DB, userID int64, bcoinsExchanged float64) (bool, error) {
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementExchangeHandler struct{}
 
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?",
-		userID, RequirementProgressExchangeType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementExchangeHandler{})
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
-	}
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementExchangeHandler) RequirementType() string {
+	return requirements.RequirementExchangeType
+}
+
+func (requirementExchangeHandler) ProgressType() string {
+	return RequirementProgressExchangeType
+}
+
+func (requirementExchangeHandler) NewRequirement() interface{} {
+	return &requirements.RequirementExchange{}
+}
+
+func (requirementExchangeHandler) NewProgress() interface{} {
+	return &RequirementProgressExchange{}
+}
+
+func (requirementExchangeHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressExchange(tx, requirement, userID)
+}
+
+func (requirementExchangeHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	exchange, ok := event.(events.BcoinsExchanged)
+	if !ok {
+		return false, nil
 	}
 
-	requirementProgressExchange, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressExchange)
+	requirementProgressExchange, ok := progress.(*RequirementProgressExchange)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressExchange)"), "")
+			"unable to convert progress to (*RequirementProgressExchange)"), "")
 	}
-	requirementExchange, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementExchange)
+	requirementExchange, ok := requirement.(requirements.RequirementExchange)
 	if !ok {
 		return false, logger.WrapError(errors.New(
 			"unable to convert PolymorphicRequirement to (RequirementExchange)"), "")
 	}
 
-	requirementProgressExchange.CurrentBCoinsExchanged += bcoinsExchanged
-	if requirementProgressExchange.CurrentBCoinsExchanged >=
-		requirementExchange.BCoinsAmount {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressExchange).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		return true, nil
-	} else if err = tx.Save(&requirementProgressExchange).Error; err != nil {
-		return false, logger.WrapError(err, "")
+	requirementProgressExchange.CurrentBCoinsExchanged += exchange.AmountBcoins
+
+	return requirementProgressExchange.CurrentBCoinsExchanged >=
+		requirementExchange.BCoinsAmount, nil
+}
+
+func (requirementExchangeHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressExchange, ok := progress.(*RequirementProgressExchange)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressExchange)"), "")
+	}
+	requirementExchange, ok := requirement.(requirements.RequirementExchange)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementExchange)"), "")
 	}
 
-	return false, nil
+	return requirementProgressExchange.CurrentBCoinsExchanged >=
+		requirementExchange.BCoinsAmount, nil
 }
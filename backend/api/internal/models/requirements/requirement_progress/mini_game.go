@@ -2,6 +2,7 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -49,74 +50,100 @@ func CreateRequirementProgressMiniGame(tx *gorm.DB, requirement *requirements.Re
 	return nil
 }
 
-// UpdateRequirementProgressMiniGameIfRequired returns true if
-// requirement completed and removes RequirementProgressMiniGame with
-// RequirementProgress.
-func UpdateRequirementProgressMiniGameIfRequired(
-	tx *gorm.DB, userID, gameID int64, betAmount, betPayout float64) (bool, error) {
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementMiniGameHandler struct{}
 
-	// check if requirement set
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?", userID, RequirementProgressMiniGameType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementMiniGameHandler{})
+}
+
+func (requirementMiniGameHandler) RequirementType() string {
+	return requirements.RequirementMiniGameType
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementMiniGameHandler) ProgressType() string {
+	return RequirementProgressMiniGameType
+}
+
+func (requirementMiniGameHandler) NewRequirement() interface{} {
+	return &requirements.RequirementMiniGame{}
+}
+
+func (requirementMiniGameHandler) NewProgress() interface{} {
+	return &RequirementProgressMiniGame{}
+}
+
+func (requirementMiniGameHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressMiniGame(tx, requirement, userID)
+}
+
+func (requirementMiniGameHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	bet, ok := event.(events.BetSettled)
+	if !ok || bet.IsBenefitBet {
+		return false, nil
 	}
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+
+	// Crash game and binary options have their own requirements
+	if bet.GameID != requirements.NvutiGameID && bet.GameID != requirements.DiceGameID &&
+		bet.GameID != requirements.RouletteGameID {
+		return false, nil
 	}
 
-	requirementProgressMiniGame, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressMiniGame)
+	requirementProgressMiniGame, ok := progress.(*RequirementProgressMiniGame)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressReplenishment)"), "")
+			"unable to convert progress to (*RequirementProgressMiniGame)"), "")
 	}
-	requirementMiniGame, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementMiniGame)
+	requirementMiniGame, ok := requirement.(requirements.RequirementMiniGame)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirement to (RequirementReplenishment)"), "")
+			"unable to convert PolymorphicRequirement to (RequirementMiniGame)"), "")
 	}
 
-	if requirementMiniGame.GameID != 0 && requirementMiniGame.GameID != gameID {
+	if requirementMiniGame.GameID != 0 && requirementMiniGame.GameID != bet.GameID {
 		return false, nil
 	}
 
-	if betAmount >= requirementMiniGame.MinBetRupee {
-		requirementProgressMiniGame.BetsAmount++
-		if betPayout > 0 {
-			requirementProgressMiniGame.WinsAmount++
-		}
-	} else {
+	if bet.FromCashRupee < requirementMiniGame.MinBetRupee {
 		return false, nil
 	}
 
-	if requirementProgressMiniGame.BetsAmount >= requirementMiniGame.BetsAmount &&
-		requirementProgressMiniGame.WinsAmount >= requirementMiniGame.WinsAmount {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressMiniGame).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
+	requirementProgressMiniGame.BetsAmount++
+	if bet.Won {
+		requirementProgressMiniGame.WinsAmount++
+	}
+
+	return requirementProgressMiniGame.completes(requirementMiniGame), nil
+}
 
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
+func (requirementMiniGameHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressMiniGame, ok := progress.(*RequirementProgressMiniGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressMiniGame)"), "")
+	}
+	previousMiniGame, ok := previous.(requirements.RequirementMiniGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert previous PolymorphicRequirement to (RequirementMiniGame)"), "")
+	}
+	requirementMiniGame, ok := requirement.(requirements.RequirementMiniGame)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementMiniGame)"), "")
+	}
 
-		return true, nil
-	} else if err = tx.Save(&requirementProgressMiniGame).Error; err != nil {
-		return false, logger.WrapError(err, "")
+	// Bets of other game or smaller bets don't count anymore
+	if previousMiniGame.GameID != requirementMiniGame.GameID ||
+		previousMiniGame.MinBetRupee != requirementMiniGame.MinBetRupee {
+		*requirementProgressMiniGame = RequirementProgressMiniGame{ID: requirementProgressMiniGame.ID}
 	}
 
-	return false, nil
+	return requirementProgressMiniGame.completes(requirementMiniGame), nil
+}
+
+func (p *RequirementProgressMiniGame) completes(requirement requirements.RequirementMiniGame) bool {
+	return p.BetsAmount >= requirement.BetsAmount && p.WinsAmount >= requirement.WinsAmount
 }
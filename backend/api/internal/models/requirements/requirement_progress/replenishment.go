@@ -2,6 +2,7 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -48,64 +49,71 @@ func CreateRequirementProgressReplenishment(tx *gorm.DB, requirement *requiremen
 	return nil
 }
 
-// UpdateRequirementProgressReplenishmentIfRequired returns true if
-// requirement completed and removes RequirementProgressReplenishment with
-// RequirementProgress.
-func UpdateRequirementProgressReplenishmentIfRequired(
-	tx *gorm.DB, userID int64, replenishmentRupeeValue float64) (bool, error) {
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementReplenishmentHandler struct{}
 
-	// check if requirement set
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?",
-		userID, RequirementProgressReplenishmentType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementReplenishmentHandler{})
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
-	}
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementReplenishmentHandler) RequirementType() string {
+	return requirements.RequirementReplenishmentType
+}
+
+func (requirementReplenishmentHandler) ProgressType() string {
+	return RequirementProgressReplenishmentType
+}
+
+func (requirementReplenishmentHandler) NewRequirement() interface{} {
+	return &requirements.RequirementReplenishment{}
+}
+
+func (requirementReplenishmentHandler) NewProgress() interface{} {
+	return &RequirementProgressReplenishment{}
+}
+
+func (requirementReplenishmentHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressReplenishment(tx, requirement, userID)
+}
+
+func (requirementReplenishmentHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	replenishment, ok := event.(events.DepositCredited)
+	if !ok {
+		return false, nil
 	}
 
-	requirementProgressReplenishment, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressReplenishment)
+	requirementProgressReplenishment, ok := progress.(*RequirementProgressReplenishment)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressReplenishment)"), "")
+			"unable to convert progress to (*RequirementProgressReplenishment)"), "")
 	}
-	requirementReplenishment, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementReplenishment)
+	requirementReplenishment, ok := requirement.(requirements.RequirementReplenishment)
 	if !ok {
 		return false, logger.WrapError(errors.New(
 			"unable to convert PolymorphicRequirement to (RequirementReplenishment)"), "")
 	}
 
 	requirementProgressReplenishment.CurrentReplenishmentRupee +=
-		replenishmentRupeeValue
-	if requirementProgressReplenishment.CurrentReplenishmentRupee >=
-		requirementReplenishment.AmountRupee {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressReplenishment).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
-
-		return true, nil
-	} else if err = tx.Save(&requirementProgressReplenishment).Error; err != nil {
-		return false, logger.WrapError(err, "")
+		replenishment.AmountRupee
+
+	return requirementProgressReplenishment.CurrentReplenishmentRupee >=
+		requirementReplenishment.AmountRupee, nil
+}
+
+func (requirementReplenishmentHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressReplenishment, ok := progress.(*RequirementProgressReplenishment)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressReplenishment)"), "")
+	}
+	requirementReplenishment, ok := requirement.(requirements.RequirementReplenishment)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementReplenishment)"), "")
 	}
 
-	return false, nil
+	return requirementProgressReplenishment.CurrentReplenishmentRupee >=
+		requirementReplenishment.AmountRupee, nil
 }
@@ -2,9 +2,11 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"fmt"
+	"reflect"
 
 	"gorm.io/gorm"
 )
@@ -19,6 +21,72 @@ type RequirementProgress struct {
 	PolymorphicRequirementProgress     interface{}              `gorm:"-"`
 }
 
+// RequirementHandler implements polymorphic requirement type together
+// with its user progress. Each type registers its handler with
+// RegisterRequirementHandler from the file of its progress.
+type RequirementHandler interface {
+	// RequirementType returns PolymorphicRequirementType of the type.
+	RequirementType() string
+	// ProgressType returns PolymorphicRequirementProgressType of the type.
+	ProgressType() string
+	// NewRequirement returns pointer to empty polymorphic requirement.
+	NewRequirement() interface{}
+	// NewProgress returns pointer to empty polymorphic requirement progress.
+	NewProgress() interface{}
+	// CreateProgress creates polymorphic requirement progress and linked
+	// RequirementProgress. Requirement should contain existing
+	// polymorphic requirement.
+	CreateProgress(tx *gorm.DB, requirement *requirements.Requirement, userID int64) error
+	// UpdateProgress tracks domain event in progress, which is pointer
+	// to loaded polymorphic progress of the requirement value. Returns
+	// true if requirement completed. Events not tracked by the
+	// type are ignored.
+	UpdateProgress(tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error)
+	// EditProgress revalidates progress, which is pointer to loaded
+	// polymorphic progress, after requirement value changed from previous.
+	// Progress is reset if params selecting counted events changed.
+	// Returns true if requirement is completed with the progress.
+	EditProgress(tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error)
+}
+
+var (
+	requirementHandlers         = map[string]RequirementHandler{}
+	requirementProgressHandlers = map[string]RequirementHandler{}
+)
+
+// RegisterRequirementHandler registers requirement type handler,
+// requirement type is registered in requirements package as well.
+// Panics if type is already registered.
+func RegisterRequirementHandler(handler RequirementHandler) {
+	if _, ok := requirementProgressHandlers[handler.ProgressType()]; ok {
+		panic(fmt.Sprintf("PolymorphicRequirementProgressType %s already registered",
+			handler.ProgressType()))
+	}
+
+	requirements.RegisterRequirementType(handler.RequirementType(), handler.NewRequirement)
+	requirementHandlers[handler.RequirementType()] = handler
+	requirementProgressHandlers[handler.ProgressType()] = handler
+}
+
+// GetRequirementHandler returns handler of polymorphic requirement type.
+func GetRequirementHandler(requirementType string) (RequirementHandler, error) {
+	handler, ok := requirementHandlers[requirementType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicRequirementType: %s", requirementType)
+	}
+
+	return handler, nil
+}
+
+func getRequirementProgressHandler(progressType string) (RequirementHandler, error) {
+	handler, ok := requirementProgressHandlers[progressType]
+	if !ok {
+		return nil, fmt.Errorf("no such PolymorphicRequirementProgressType: %s", progressType)
+	}
+
+	return handler, nil
+}
+
 // PreloadPolymorphicRequirementProgress preloads RequirementProgress
 // polymorphic relation PolymorphicRequirementProgress by its type and id.
 func (rp *RequirementProgress) PreloadPolymorphicRequirementProgress(tx *gorm.DB) error {
@@ -26,105 +94,207 @@ func (rp *RequirementProgress) PreloadPolymorphicRequirementProgress(tx *gorm.DB
 		tx = db.DB
 	}
 
-	var err error
-	switch rp.PolymorphicRequirementProgressType {
-	case RequirementProgressClickerType:
-		var requirementProgressClicker RequirementProgressClicker
-		err = tx.First(
-			&requirementProgressClicker, rp.PolymorphicRequirementProgressID).Error
+	handler, err := getRequirementProgressHandler(rp.PolymorphicRequirementProgressType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	polymorphicRequirementProgress := handler.NewProgress()
+	err = tx.First(polymorphicRequirementProgress, rp.PolymorphicRequirementProgressID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	// Progresses are stored by value
+	rp.PolymorphicRequirementProgress = reflect.ValueOf(polymorphicRequirementProgress).Elem().Interface()
+
+	return nil
+}
+
+// CreatePolymorphicRequirementProgress creates polymorphic requirement
+// progress and linked requirement progress. Requirement parameter should
+// contain existing polymorphic requirement.
+func CreatePolymorphicRequirementProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	handler, err := GetRequirementHandler(requirement.PolymorphicRequirementType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = handler.CreateProgress(tx, requirement, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// UpdateRequirementProgresses tracks domain event in user progresses of
+// given requirements. Returns true if any requirement completed, completed
+// polymorphic progress is removed with RequirementProgress.
+func UpdateRequirementProgresses(
+	tx *gorm.DB, userID int64, requirementIDs []int64, event events.Event) (bool, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if len(requirementIDs) == 0 {
+		return false, nil
+	}
+
+	var reqProgs []RequirementProgress
+	err := tx.Preload("Requirement").Find(&reqProgs,
+		"user_id = ? and requirement_id in ?", userID, requirementIDs).Error
+	if err != nil {
+		return false, logger.WrapError(err, "")
+	}
+
+	var completed bool
+	for i := range reqProgs {
+		handler, err := getRequirementProgressHandler(reqProgs[i].PolymorphicRequirementProgressType)
 		if err != nil {
-			return logger.WrapError(err, "")
+			return false, logger.WrapError(err, "")
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressClicker
-	case RequirementProgressExchangeType:
-		var requirementProgressExchange RequirementProgressExchange
-		err = tx.First(
-			&requirementProgressExchange, rp.PolymorphicRequirementProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
+
+		if err = reqProgs[i].Requirement.PreloadPolymorphicRequirement(tx); err != nil {
+			return false, logger.WrapError(err, "")
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressExchange
-	case RequirementProgressMiniGameType:
-		var requirementProgressMiniGame RequirementProgressMiniGame
-		err = tx.First(
-			&requirementProgressMiniGame, rp.PolymorphicRequirementProgressID).Error
+
+		progress := handler.NewProgress()
+		err = tx.First(progress, reqProgs[i].PolymorphicRequirementProgressID).Error
 		if err != nil {
-			return logger.WrapError(err, "")
+			return false, logger.WrapError(err, "")
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressMiniGame
-	case RequirementProgressReplenishmentType:
-		var requirementProgressReplenishment RequirementProgressReplenishment
-		err = tx.First(
-			&requirementProgressReplenishment, rp.PolymorphicRequirementProgressID).Error
+
+		unchanged := reflect.ValueOf(progress).Elem().Interface()
+		done, err := handler.UpdateProgress(
+			tx, userID, reqProgs[i].Requirement.PolymorphicRequirement, progress, event)
 		if err != nil {
-			return logger.WrapError(err, "")
+			return false, logger.WrapError(err, "")
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressReplenishment
-	case RequirementProgressTurnoverType:
-		var requirementProgressTurnover RequirementProgressTurnover
-		err = tx.First(
-			&requirementProgressTurnover, rp.PolymorphicRequirementProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
+
+		if !done {
+			// Event is not tracked by the requirement
+			if reflect.DeepEqual(unchanged, reflect.ValueOf(progress).Elem().Interface()) {
+				continue
+			}
+
+			if err = tx.Save(progress).Error; err != nil {
+				return false, logger.WrapError(err, "")
+			}
+			continue
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressTurnover
-	case RequirementProgressBinaryOptionType:
-		var requirementProgressBinaryOption RequirementProgressBinaryOption
-		err = tx.First(
-			&requirementProgressBinaryOption, rp.PolymorphicRequirementProgressID).Error
-		if err != nil {
-			return logger.WrapError(err, "")
+
+		// requirement completed
+		if err = tx.Delete(progress).Error; err != nil {
+			return false, logger.WrapError(err, "")
+		}
+
+		if err = tx.Delete(&reqProgs[i]).Error; err != nil {
+			return false, logger.WrapError(err, "")
 		}
-		rp.PolymorphicRequirementProgress = requirementProgressBinaryOption
-	default:
-		return logger.WrapError(err, fmt.Sprintf(
-			"no such PolymorphicRequirementProgressType: %s",
-			rp.PolymorphicRequirementProgressType))
+
+		completed = true
 	}
 
-	return nil
+	return completed, nil
 }
 
-// CreatePolymorphicRequirementProgress creates polymorphic requirement
-// progress and linked requirement progress. Requirement parameter should
-// contain existing polymorphic requirement.
-func CreatePolymorphicRequirementProgress(
-	tx *gorm.DB, requirement *requirements.Requirement, userID int64) (err error) {
-	switch requirement.PolymorphicRequirementType {
-	case requirements.RequirementClickerType:
-		if err = CreateRequirementProgressClicker(
-			tx, requirement, userID); err != nil {
+// EditRequirementProgresses revalidates user progresses of the requirement
+// after its polymorphic requirement changed from previous value, see
+// RequirementHandler.EditProgress. Completed polymorphic progress is
+// removed with RequirementProgress like in UpdateRequirementProgresses.
+// Requirement should contain edited polymorphic requirement.
+func EditRequirementProgresses(tx *gorm.DB, requirement *requirements.Requirement, previous interface{}) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	handler, err := GetRequirementHandler(requirement.PolymorphicRequirementType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var reqProgs []RequirementProgress
+	err = tx.Find(&reqProgs, "requirement_id = ?", requirement.ID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range reqProgs {
+		progress := handler.NewProgress()
+		err = tx.First(progress, reqProgs[i].PolymorphicRequirementProgressID).Error
+		if err != nil {
 			return logger.WrapError(err, "")
 		}
-	case requirements.RequirementExchangeType:
-		if err = CreateRequirementProgressExchange(
-			tx, requirement, userID); err != nil {
+
+		done, err := handler.EditProgress(tx, reqProgs[i].UserID,
+			previous, requirement.PolymorphicRequirement, progress)
+		if err != nil {
 			return logger.WrapError(err, "")
 		}
-	case requirements.RequirementMiniGameType:
-		if err = CreateRequirementProgressMiniGame(
-			tx, requirement, userID); err != nil {
-			return logger.WrapError(err, "")
+
+		if !done {
+			if err = tx.Save(progress).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+			continue
 		}
-	case requirements.RequirementReplenishmentType:
-		if err = CreateRequirementProgressReplenishment(
-			tx, requirement, userID); err != nil {
+
+		if err = tx.Delete(progress).Error; err != nil {
 			return logger.WrapError(err, "")
 		}
-	case requirements.RequirementTurnoverType:
-		if err = CreateRequirementProgressTurnover(
-			tx, requirement, userID); err != nil {
+
+		if err = tx.Delete(&reqProgs[i]).Error; err != nil {
 			return logger.WrapError(err, "")
 		}
-	case requirements.RequirementBinaryOptionType:
-		if err = CreateRequirementProgressBinaryOption(
-			tx, requirement, userID); err != nil {
+	}
+
+	return nil
+}
+
+// DeleteRequirementProgress deletes requirement progress with
+// its polymorphic requirement progress.
+func DeleteRequirementProgress(tx *gorm.DB, rp *RequirementProgress) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	handler, err := getRequirementProgressHandler(rp.PolymorphicRequirementProgressType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Delete(handler.NewProgress(), rp.PolymorphicRequirementProgressID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = tx.Delete(rp).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// DeleteUserRequirementProgresses deletes user requirement progresses
+// of given requirements. RequirementIDs is a slice of ids or a subquery
+// selecting them. Should be used when user progress is reset.
+func DeleteUserRequirementProgresses(tx *gorm.DB, userID int64, requirementIDs interface{}) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var reqProgs []RequirementProgress
+	err := tx.Find(&reqProgs, "user_id = ? AND requirement_id IN (?)", userID, requirementIDs).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range reqProgs {
+		if err := DeleteRequirementProgress(tx, &reqProgs[i]); err != nil {
 			return logger.WrapError(err, "")
 		}
-	default:
-		return logger.WrapError(err, fmt.Sprintf(
-			"no such polymorphicRequirementType: %s",
-			requirement.PolymorphicRequirementType))
 	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package requirement_progress
 
 import (
 	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -52,41 +53,46 @@ func CreateRequirementProgressTurnover(tx *gorm.DB, requirement *requirements.Re
 	return nil
 }
 
-// UpdateRequirementProgressTurnoverIfRequired returns true if
-// requirement completed and removes RequirementProgressTurnover with
-// RequirementProgress.
-func UpdateRequirementProgressTurnoverIfRequired(tx *gorm.DB, userID int64, rupeeSpent float64) (bool, error) {
-	if tx == nil {
-		tx = db.DB
-	}
+type requirementTurnoverHandler struct{}
 
-	// check if requirement set
-	var requirementProgress RequirementProgress
-	err := tx.Preload("Requirement").First(&requirementProgress,
-		"user_id = ? and polymorphic_requirement_progress_type = ?", userID, RequirementProgressTurnoverType).Error
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, nil // requirement not set
-	} else if err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func init() {
+	RegisterRequirementHandler(requirementTurnoverHandler{})
+}
 
-	// if set, update progress
-	if err = requirementProgress.PreloadPolymorphicRequirementProgress(tx); err != nil {
-		return false, logger.WrapError(err, "")
-	}
+func (requirementTurnoverHandler) RequirementType() string {
+	return requirements.RequirementTurnoverType
+}
+
+func (requirementTurnoverHandler) ProgressType() string {
+	return RequirementProgressTurnoverType
+}
 
-	if err = requirementProgress.Requirement.PreloadPolymorphicRequirement(tx); err != nil {
-		return false, logger.WrapError(err, "")
+func (requirementTurnoverHandler) NewRequirement() interface{} {
+	return &requirements.RequirementTurnover{}
+}
+
+func (requirementTurnoverHandler) NewProgress() interface{} {
+	return &RequirementProgressTurnover{}
+}
+
+func (requirementTurnoverHandler) CreateProgress(
+	tx *gorm.DB, requirement *requirements.Requirement, userID int64) error {
+	return CreateRequirementProgressTurnover(tx, requirement, userID)
+}
+
+func (requirementTurnoverHandler) UpdateProgress(
+	tx *gorm.DB, userID int64, requirement, progress interface{}, event events.Event) (bool, error) {
+	bet, ok := event.(events.BetPlaced)
+	if !ok || bet.IsBenefitBet || bet.FromCashRupee == 0 {
+		return false, nil
 	}
 
-	requirementProgressTurnover, ok := requirementProgress.
-		PolymorphicRequirementProgress.(RequirementProgressTurnover)
+	requirementProgressTurnover, ok := progress.(*RequirementProgressTurnover)
 	if !ok {
 		return false, logger.WrapError(errors.New(
-			"unable to convert PolymorphicRequirementProgress to (RequirementProgressTurnover)"), "")
+			"unable to convert progress to (*RequirementProgressTurnover)"), "")
 	}
-	requirementTurnover, ok := requirementProgress.Requirement.
-		PolymorphicRequirement.(requirements.RequirementTurnover)
+	requirementTurnover, ok := requirement.(requirements.RequirementTurnover)
 	if !ok {
 		return false, logger.WrapError(errors.New(
 			"unable to convert PolymorphicRequirement to (RequirementTurnover)"), "")
@@ -97,26 +103,33 @@ func UpdateRequirementProgressTurnoverIfRequired(tx *gorm.DB, userID int64, rupe
 
 	// Progress expired
 	if timeDuration != 0 && startedAt.Add(timeDuration).Before(time.Now()) {
-		requirementProgressTurnover.CurrentRupeeTurnover = rupeeSpent
+		requirementProgressTurnover.CurrentRupeeTurnover = bet.FromCashRupee
 		requirementProgressTurnover.StartedAt = time.Now()
 	} else {
-		requirementProgressTurnover.CurrentRupeeTurnover += rupeeSpent
+		requirementProgressTurnover.CurrentRupeeTurnover += bet.FromCashRupee
 	}
 
-	if requirementProgressTurnover.CurrentRupeeTurnover >= requirementTurnover.AmountRupee {
-		// requirement completed
-		if err = tx.Delete(&requirementProgressTurnover).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
+	return requirementProgressTurnover.CurrentRupeeTurnover >= requirementTurnover.AmountRupee, nil
+}
 
-		if err = tx.Delete(&requirementProgress).Error; err != nil {
-			return false, logger.WrapError(err, "")
-		}
+func (requirementTurnoverHandler) EditProgress(
+	tx *gorm.DB, userID int64, previous, requirement, progress interface{}) (bool, error) {
+	requirementProgressTurnover, ok := progress.(*RequirementProgressTurnover)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert progress to (*RequirementProgressTurnover)"), "")
+	}
+	requirementTurnover, ok := requirement.(requirements.RequirementTurnover)
+	if !ok {
+		return false, logger.WrapError(errors.New(
+			"unable to convert PolymorphicRequirement to (RequirementTurnover)"), "")
+	}
 
-		return true, nil
-	} else if err = tx.Save(&requirementProgressTurnover).Error; err != nil {
-		return false, logger.WrapError(err, "")
+	// Expired progress starts over with the next bet
+	timeDuration := time.Duration(requirementTurnover.TimeDuration) * time.Second
+	if timeDuration != 0 && requirementProgressTurnover.StartedAt.Add(timeDuration).Before(time.Now()) {
+		return false, nil
 	}
 
-	return false, nil
+	return requirementProgressTurnover.CurrentRupeeTurnover >= requirementTurnover.AmountRupee, nil
 }
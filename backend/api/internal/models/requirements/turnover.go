@@ -3,7 +3,7 @@ package requirements
 const RequirementTurnoverType = "requirement_turnover"
 
 type RequirementTurnover struct {
-	ID           int64 `gorm:"primaryKey;autoIncrement"`
-	AmountRupee  float64
-	TimeDuration int64
+	ID           int64   `gorm:"primaryKey;autoIncrement"`
+	AmountRupee  float64 `validate:"gt=0"`
+	TimeDuration int64   `validate:"min=0"`
 }
@@ -2,23 +2,45 @@ package models
 
 import "time"
 
+// RouletteX14Bet outcomes. Bet stays pending until its round is spun.
+const (
+	RouletteX14BetPending = "pending"
+	RouletteX14BetWin     = "win"
+	RouletteX14BetLose    = "lose"
+	RouletteX14BetRefund  = "refund"
+)
+
+// RouletteX14Bet types. Color bet wins if BetColor equals winning
+// sector color, number bet wins if BetNumber equals winning sector number.
+const (
+	RouletteX14BetTypeColor  = "color"
+	RouletteX14BetTypeNumber = "number"
+)
+
+// RouletteX14Bet is a single entry of the user bet slip.
 type RouletteX14Bet struct {
 	ID               int64   `gorm:"primaryKey,autoIncrement"`
 	UserID           int64   `gorm:"not null;index"`
+	GameResultID     int64   `gorm:"not null;index"`
 	Amount           float64 `gorm:"not null"`
 	IsBenefitBet     bool
 	FromCashBalance  float64 `json:"-"`
 	FromBonusBalance float64 `json:"-"`
+	BetType          string  `gorm:"not null;default:'color'"`
 	BetColor         string  `gorm:"not null"`
-	Outcome          string
+	BetNumber        *int
+	Outcome          string `gorm:"index"`
 	Payout           float64
 	CreatedAt        time.Time
 }
 
+// RouletteX14GameResult is a shared roulette round. It is created
+// when betting window opens, WinningColor and SectorNumber are set
+// on spin, after that SettledAt is set when all round bets are settled.
 type RouletteX14GameResult struct {
-	ID           int64     `gorm:"primaryKey,autoIncrement"`
-	UserID       int64     `gorm:"not null;index"`
-	WinningColor string    `gorm:"not null"`
-	SectorNumber int       `gorm:"not null"`
-	CreatedAt    time.Time `gorm:"not null"`
+	ID           int64      `gorm:"primaryKey,autoIncrement"`
+	WinningColor string     `gorm:"not null"`
+	SectorNumber int        `gorm:"not null"`
+	SettledAt    *time.Time `gorm:"index"`
+	CreatedAt    time.Time  `gorm:"not null"`
 }
@@ -13,19 +13,20 @@ type TravePassLevelBenefit struct {
 	ID               int64            `gorm:"primaryKey;autoIncrement"`
 	TravePassLevelID int64            `gorm:"index"`
 	BenefitID        int64            `gorm:"index"`
+	Premium          bool             `gorm:"index;not null;default:false"`
 	Benefit          benefits.Benefit `gorm:"foreignKey:BenefitID;constraint:OnDelete:SET NULL;"`
 }
 
-// CreateUserBenefitProgresses creates BenefitProgresses with trave pass level id.
-// Should be used on trave pass level up.
-func CreateUserBenefitProgresses(tx *gorm.DB, userID, currentLevelID int64) error {
+// CreateUserBenefitProgresses creates BenefitProgresses with trave pass level id,
+// premium or free ones. Should be used on trave pass level up.
+func CreateUserBenefitProgresses(tx *gorm.DB, userID, currentLevelID int64, premium bool) error {
 	if tx == nil {
 		tx = db.DB
 	}
 
 	var levelBenefits []TravePassLevelBenefit
 
-	err := tx.Preload("Benefit").Find(&levelBenefits, "trave_pass_level_id = ?", currentLevelID).Error
+	err := tx.Preload("Benefit").Find(&levelBenefits, "trave_pass_level_id = ? AND premium = ?", currentLevelID, premium).Error
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
@@ -3,28 +3,39 @@ package travepass
 import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/requirements/requirement_progress"
 	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// TravePassLevel is a level of the season ladder, Number
+// is level position in the season starting from 0.
+// PremiumBenefits are given only to users with premium pass.
 type TravePassLevel struct {
-	ID           int64                       `gorm:"primaryKey;autoIncrement"`
-	Requirements []TravePassLevelRequirement `gorm:"foreignKey:TravePassLevelID"`
-	Benefits     []TravePassLevelBenefit     `gorm:"foreignKey:TravePassLevelID"`
+	ID              int64                       `gorm:"primaryKey;autoIncrement"`
+	SeasonID        int64                       `gorm:"index"`
+	Number          int64                       `gorm:"index"`
+	Requirements    []TravePassLevelRequirement `gorm:"foreignKey:TravePassLevelID"`
+	Benefits        []TravePassLevelBenefit     `gorm:"foreignKey:TravePassLevelID"`
+	PremiumBenefits []TravePassLevelBenefit     `gorm:"foreignKey:TravePassLevelID"`
 }
 
-// GetAllTravePassLevelsWithRequirementsAndBenefits loads all TravePassLevel with
-// it's polymorphic relations.
-func GetAllTravePassLevelsWithRequirementsAndBenefits(tx *gorm.DB) (*[]TravePassLevel, error) {
+// GetAllTravePassLevelsWithRequirementsAndBenefits loads all season TravePassLevel
+// with it's polymorphic relations ordered by level number.
+func GetAllTravePassLevelsWithRequirementsAndBenefits(tx *gorm.DB, seasonID int64) (*[]TravePassLevel, error) {
 	if tx == nil {
 		tx = db.DB
 	}
 
 	var levels []TravePassLevel
-	err := tx.Preload("Benefits.Benefit").Preload("Benefits").
-		Preload("Requirements.Requirement").Preload("Requirements").Find(&levels).Error
+	err := tx.Preload("Benefits.Benefit").Preload("Benefits", "premium = ?", false).
+		Preload("PremiumBenefits.Benefit").Preload("PremiumBenefits", "premium = ?", true).
+		Preload("Requirements.Requirement").Preload("Requirements").
+		Where("season_id = ?", seasonID).Order("number").Find(&levels).Error
 	if err != nil {
 		return nil, logger.WrapError(err, "")
 	}
@@ -41,32 +52,233 @@ func GetAllTravePassLevelsWithRequirementsAndBenefits(tx *gorm.DB) (*[]TravePass
 				return nil, logger.WrapError(err, "")
 			}
 		}
+
+		for benIdx := range levels[levelIdx].PremiumBenefits {
+			if err = levels[levelIdx].PremiumBenefits[benIdx].Benefit.PreloadPolymorphicBenefit(tx); err != nil {
+				return nil, logger.WrapError(err, "")
+			}
+		}
 	}
 
 	return &levels, nil
 }
 
-// CheckAndUpgradeTravePassLevel checks count of uncompleted trave pass level
-// requirements by id of trave pass level. If all requirements completed(deleted)
-// it applies PolymorphicBenefitProgresses and next level PolymorphicRequirementProgresses to user.
-func CheckAndUpgradeTravePassLevel(tx *gorm.DB, userID int64) error {
+// GetTravePassLevel returns level by id or nil if it does not exist.
+func GetTravePassLevel(tx *gorm.DB, levelID int64) (*TravePassLevel, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var level TravePassLevel
+	err := tx.First(&level, levelID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &level, nil
+}
+
+// NextLevel returns the next level of the season or nil
+// if level is the last one.
+func (l *TravePassLevel) NextLevel(tx *gorm.DB) (*TravePassLevel, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var next TravePassLevel
+	err := tx.Where("season_id = ? AND number > ?", l.SeasonID, l.Number).
+		Order("number").First(&next).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &next, nil
+}
+
+func init() {
+	// Reached levels are recorded and shown to user on request
+	events.Subscribe(func(tx *gorm.DB, event events.Event) error {
+		_, err := UpdateAndUpgradeTravePassLevel(tx, event)
+		return err
+	})
+}
+
+// UpdateAndUpgradeTravePassLevel tracks event in user next level requirement
+// progresses and upgrades user level while requirements are completed. The
+// event is tracked again in requirements of every reached level, so one
+// action can pass several levels. Returns all reached levels, they are
+// recorded for user as well, see GetUnseenTravePassReachedLevels.
+func UpdateAndUpgradeTravePassLevel(tx *gorm.DB, event events.Event) ([]TravePassLevel, error) {
 	if tx == nil {
 		tx = db.DB
 	}
 
+	userID := event.EventUserID()
+
+	// Action should count toward requirements of the active season
+	if err := EnsureUserTravePassSeason(tx, userID); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var reachedLevels []TravePassLevel
+	for {
+		requirementIDs, err := getUserTravePassNextLevelRequirementIDs(tx, userID)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+
+		done, err := requirement_progress.UpdateRequirementProgresses(
+			tx, userID, requirementIDs, event)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		if !done {
+			break
+		}
+
+		level, err := upgradeTravePassLevel(tx, userID)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		if level == nil {
+			break
+		}
+
+		reachedLevels = append(reachedLevels, *level)
+	}
+
+	logReachedTravePassLevels(userID, reachedLevels)
+	return reachedLevels, nil
+}
+
+// getUserTravePassNextLevelRequirementIDs returns requirement ids of the
+// level following user level, empty if user has no level or reached
+// the last level of the season.
+func getUserTravePassNextLevelRequirementIDs(tx *gorm.DB, userID int64) ([]int64, error) {
+	var levelID int64
+	err := tx.Model(&models.User{}).Where("id = ?", userID).
+		Pluck("trave_pass_level_id", &levelID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	level, err := GetTravePassLevel(tx, levelID)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if level == nil {
+		return nil, nil
+	}
+
+	nextLevel, err := level.NextLevel(tx)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if nextLevel == nil {
+		return nil, nil
+	}
+
+	var requirementIDs []int64
+	err = tx.Model(&TravePassLevelRequirement{}).
+		Where("trave_pass_level_id = ?", nextLevel.ID).
+		Pluck("requirement_id", &requirementIDs).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return requirementIDs, nil
+}
+
+// CheckAndUpgradeTravePassLevel upgrades user level while next level
+// requirements are completed. User is moved to the active season
+// first. Returns all reached levels, they are recorded for user
+// as well, see GetUnseenTravePassReachedLevels.
+func CheckAndUpgradeTravePassLevel(tx *gorm.DB, userID int64) ([]TravePassLevel, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if err := EnsureUserTravePassSeason(tx, userID); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var reachedLevels []TravePassLevel
+	for {
+		level, err := upgradeTravePassLevel(tx, userID)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		if level == nil {
+			break
+		}
+
+		reachedLevels = append(reachedLevels, *level)
+	}
+
+	logReachedTravePassLevels(userID, reachedLevels)
+	return reachedLevels, nil
+}
+
+func logReachedTravePassLevels(userID int64, levels []TravePassLevel) {
+	for _, level := range levels {
+		logger.Info("User %d reached trave pass level %d of season %d",
+			userID, level.Number, level.SeasonID)
+	}
+}
+
+// upgradeTravePassLevel checks count of uncompleted next trave pass level
+// requirements. If all requirements completed(deleted) it applies
+// PolymorphicBenefitProgresses and next level PolymorphicRequirementProgresses
+// to user. Returns reached level or nil if level was not upgraded. Levels
+// of ended season are not upgraded.
+func upgradeTravePassLevel(tx *gorm.DB, userID int64) (*TravePassLevel, error) {
 	var user models.User
 	err := tx.First(&user, userID).Error
 	if err != nil {
-		return logger.WrapError(err, "")
+		return nil, logger.WrapError(err, "")
+	}
+
+	level, err := GetTravePassLevel(tx, user.TravePassLevelID)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if level == nil {
+		return nil, nil
+	}
+
+	var season TravePassSeason
+	if err = tx.First(&season, level.SeasonID).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if !season.IsActive(time.Now()) {
+		return nil, nil
+	}
+
+	nextLevel, err := level.NextLevel(tx)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	// Last level of the season reached
+	if nextLevel == nil {
+		return nil, nil
 	}
 
 	// Get level requirements ids
 	var nextLevelRequirementsIDs []int64
 	err = tx.Model(&TravePassLevelRequirement{}).
-		Where("trave_pass_level_id = ?", user.TravePassLevelID+1).
+		Where("trave_pass_level_id = ?", nextLevel.ID).
 		Pluck("requirement_id", &nextLevelRequirementsIDs).Error
 	if err != nil {
-		return logger.WrapError(err, "")
+		return nil, logger.WrapError(err, "")
+	}
+
+	// Level without requirements is not configured yet
+	if len(nextLevelRequirementsIDs) == 0 {
+		return nil, nil
 	}
 
 	// Get count of user LEVEL uncompleted requirements
@@ -76,31 +288,55 @@ func CheckAndUpgradeTravePassLevel(tx *gorm.DB, userID int64) error {
 		Where("user_id = ? and requirement_id in ?", userID, nextLevelRequirementsIDs).
 		Scan(&requirementsProgressCount).Error
 	if err != nil {
-		return logger.WrapError(err, "")
+		return nil, logger.WrapError(err, "")
 	}
 
 	// if all requirements completed - give benefits, set new level and create new RequirementsProgresses
 	if requirementsProgressCount == 0 {
-		if user.TravePassLevelID == 60 {
-			return nil
+		user.TravePassLevelID = nextLevel.ID
+
+		if err = tx.Save(&user).Error; err != nil {
+			return nil, logger.WrapError(err, "")
 		}
 
-		user.TravePassLevelID += 1
+		if err = saveUserTravePassSeasonProgress(tx, userID, nextLevel); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
 
-		if err = tx.Save(&user).Error; err != nil {
-			return logger.WrapError(err, "")
+		if err = saveTravePassReachedLevel(tx, userID, nextLevel); err != nil {
+			return nil, logger.WrapError(err, "")
 		}
 
 		if err = CreateUserBenefitProgresses(
-			tx, userID, user.TravePassLevelID); err != nil {
-			return logger.WrapError(err, "")
+			tx, userID, nextLevel.ID, false); err != nil {
+			return nil, logger.WrapError(err, "")
 		}
 
-		if err = CreateUserRequirementProgresses(
-			tx, userID, user.TravePassLevelID+1); err != nil {
-			return logger.WrapError(err, "")
+		premium, err := isUserTravePassSeasonPremium(tx, userID, nextLevel.SeasonID)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+
+		if premium {
+			if err = CreateUserBenefitProgresses(
+				tx, userID, nextLevel.ID, true); err != nil {
+				return nil, logger.WrapError(err, "")
+			}
 		}
+
+		levelAfterNext, err := nextLevel.NextLevel(tx)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		if levelAfterNext != nil {
+			if err = CreateUserRequirementProgresses(
+				tx, userID, levelAfterNext.ID); err != nil {
+				return nil, logger.WrapError(err, "")
+			}
+		}
+
+		return nextLevel, nil
 	}
 
-	return nil
+	return nil, nil
 }
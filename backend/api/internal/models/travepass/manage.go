@@ -0,0 +1,508 @@
+package travepass
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/internal/models/requirements/requirement_progress"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStartLevel         = errors.New("starting level of the season can't be changed")
+	ErrLevelReached       = errors.New("level is reached by users")
+	ErrInvalidLevelsOrder = errors.New("order should contain every season level except the starting one")
+)
+
+// AddTravePassLevel appends level without requirements and benefits
+// to the end of the season ladder. Level can't be reached until
+// it gets requirements.
+func AddTravePassLevel(tx *gorm.DB, seasonID int64) (*TravePassLevel, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var season TravePassSeason
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&season, seasonID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var lastNumber int64
+	err = tx.Model(&TravePassLevel{}).Where("season_id = ?", seasonID).
+		Select("COALESCE(MAX(number), -1)").Scan(&lastNumber).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	level := TravePassLevel{SeasonID: seasonID, Number: lastNumber + 1}
+	if err = tx.Create(&level).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	// Users on the former last level start tracking the new level
+	if err = SyncTravePassSeasonProgresses(tx, seasonID); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &level, nil
+}
+
+// getMaxReachedTravePassLevelNumber returns number of the highest
+// season level reached by any user.
+func getMaxReachedTravePassLevelNumber(tx *gorm.DB, seasonID int64) (int64, error) {
+	var maxReachedNumber int64
+	err := tx.Model(&TravePassSeasonProgress{}).Where("season_id = ?", seasonID).
+		Select("COALESCE(MAX(level_number), 0)").Scan(&maxReachedNumber).Error
+	if err != nil {
+		return 0, logger.WrapError(err, "")
+	}
+
+	return maxReachedNumber, nil
+}
+
+// DeleteTravePassLevel deletes level with its requirements and benefits
+// and shifts the following levels down. Levels up to the highest level
+// reached by any user can't be deleted.
+func DeleteTravePassLevel(tx *gorm.DB, levelID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var level TravePassLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&level, levelID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if level.Number == 0 {
+		return ErrStartLevel
+	}
+
+	maxReachedNumber, err := getMaxReachedTravePassLevelNumber(tx, level.SeasonID)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if level.Number <= maxReachedNumber {
+		return ErrLevelReached
+	}
+
+	var levelRequirements []TravePassLevelRequirement
+	err = tx.Find(&levelRequirements, "trave_pass_level_id = ?", levelID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	for i := range levelRequirements {
+		if err = deleteTravePassLevelRequirement(tx, &levelRequirements[i]); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	var levelBenefits []TravePassLevelBenefit
+	err = tx.Find(&levelBenefits, "trave_pass_level_id = ?", levelID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	for i := range levelBenefits {
+		if err = deleteTravePassLevelBenefit(tx, &levelBenefits[i]); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	if err = tx.Delete(&level).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Model(&TravePassLevel{}).
+		Where("season_id = ? AND number > ?", level.SeasonID, level.Number).
+		Update("number", gorm.Expr("number - 1")).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = SyncTravePassSeasonProgresses(tx, level.SeasonID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// ReorderTravePassLevels sets season levels order, levelIDs should contain
+// every season level except the starting one, which stays first. Levels
+// up to the highest level reached by any user keep their places.
+func ReorderTravePassLevels(tx *gorm.DB, seasonID int64, levelIDs []int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var levels []TravePassLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&levels, "season_id = ? AND number > 0", seasonID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if len(levels) != len(levelIDs) {
+		return ErrInvalidLevelsOrder
+	}
+
+	maxReachedNumber, err := getMaxReachedTravePassLevelNumber(tx, seasonID)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	// Level number by level id
+	seasonLevels := make(map[int64]int64, len(levels))
+	for _, level := range levels {
+		seasonLevels[level.ID] = level.Number
+	}
+
+	for i, levelID := range levelIDs {
+		number, ok := seasonLevels[levelID]
+		if !ok {
+			return ErrInvalidLevelsOrder
+		}
+		// Level id is met twice
+		delete(seasonLevels, levelID)
+
+		newNumber := int64(i + 1)
+		if number != newNumber && (number <= maxReachedNumber || newNumber <= maxReachedNumber) {
+			return ErrLevelReached
+		}
+
+		err = tx.Model(&TravePassLevel{}).Where("id = ?", levelID).
+			Update("number", newNumber).Error
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	if err = SyncTravePassSeasonProgresses(tx, seasonID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// AddTravePassLevelRequirement attaches existing requirement to the level.
+// Users who are currently on the previous level get progress of it.
+func AddTravePassLevelRequirement(tx *gorm.DB, levelID int64, requirement *requirements.Requirement) (*TravePassLevelRequirement, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var level TravePassLevel
+	if err := tx.First(&level, levelID).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if level.Number == 0 {
+		return nil, ErrStartLevel
+	}
+
+	levelRequirement := TravePassLevelRequirement{
+		TravePassLevelID: levelID, RequirementID: requirement.ID, Requirement: *requirement}
+	if err := tx.Omit("Requirement").Create(&levelRequirement).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var userIDs []int64
+	err := tx.Model(&TravePassSeasonProgress{}).
+		Where("next_level_id = ? AND archived_at IS NULL", levelID).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	for _, userID := range userIDs {
+		if err = requirement_progress.CreatePolymorphicRequirementProgress(
+			tx, requirement, userID); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+	}
+
+	return &levelRequirement, nil
+}
+
+// EditTravePassLevelRequirement saves edited polymorphic requirement of
+// the level and revalidates users progress of it, see
+// requirement_progress.EditRequirementProgresses. Users who completed
+// the level requirements after edit are upgraded. Requirement should
+// contain its polymorphic requirement before edit.
+func EditTravePassLevelRequirement(tx *gorm.DB, requirement *requirements.Requirement, polymorphicRequirement interface{}) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var levelRequirement TravePassLevelRequirement
+	err := tx.First(&levelRequirement, "requirement_id = ?", requirement.ID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var level TravePassLevel
+	if err = tx.First(&level, levelRequirement.TravePassLevelID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	previous := requirement.PolymorphicRequirement
+	if err = tx.Save(polymorphicRequirement).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = requirement.PreloadPolymorphicRequirement(tx); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = requirement_progress.EditRequirementProgresses(tx, requirement, previous); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = SyncTravePassSeasonProgresses(tx, level.SeasonID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// DeleteTravePassLevelRequirement deletes requirement from its level with
+// users progress of it. Users who completed the rest of the level
+// requirements are upgraded.
+func DeleteTravePassLevelRequirement(tx *gorm.DB, requirementID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var levelRequirement TravePassLevelRequirement
+	err := tx.First(&levelRequirement, "requirement_id = ?", requirementID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var level TravePassLevel
+	if err = tx.First(&level, levelRequirement.TravePassLevelID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = deleteTravePassLevelRequirement(tx, &levelRequirement); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = SyncTravePassSeasonProgresses(tx, level.SeasonID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+func deleteTravePassLevelRequirement(tx *gorm.DB, levelRequirement *TravePassLevelRequirement) error {
+	var reqProgs []requirement_progress.RequirementProgress
+	err := tx.Find(&reqProgs, "requirement_id = ?", levelRequirement.RequirementID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for i := range reqProgs {
+		if err = requirement_progress.DeleteRequirementProgress(tx, &reqProgs[i]); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	if err = tx.Delete(levelRequirement).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var requirement requirements.Requirement
+	if err = tx.First(&requirement, levelRequirement.RequirementID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	polymorphicRequirement, err := requirements.NewPolymorphicRequirement(requirement.PolymorphicRequirementType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err = tx.Delete(polymorphicRequirement, requirement.PolymorphicRequirementID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = tx.Delete(&requirement).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// AddTravePassLevelBenefit attaches existing benefit to the level free or
+// premium track. Benefit is given to users who reach the level after
+// it was added.
+func AddTravePassLevelBenefit(tx *gorm.DB, levelID int64, benefit *benefits.Benefit, premium bool) (*TravePassLevelBenefit, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var level TravePassLevel
+	if err := tx.First(&level, levelID).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if level.Number == 0 {
+		return nil, ErrStartLevel
+	}
+
+	levelBenefit := TravePassLevelBenefit{
+		TravePassLevelID: levelID, BenefitID: benefit.ID, Premium: premium, Benefit: *benefit}
+	if err := tx.Omit("Benefit").Create(&levelBenefit).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &levelBenefit, nil
+}
+
+// DeleteTravePassLevelBenefit deletes benefit from its level. Benefit
+// progresses already given to users are kept.
+func DeleteTravePassLevelBenefit(tx *gorm.DB, benefitID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var levelBenefit TravePassLevelBenefit
+	err := tx.First(&levelBenefit, "benefit_id = ?", benefitID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return deleteTravePassLevelBenefit(tx, &levelBenefit)
+}
+
+func deleteTravePassLevelBenefit(tx *gorm.DB, levelBenefit *TravePassLevelBenefit) error {
+	if err := tx.Delete(levelBenefit).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	// Benefit stays referenced by users benefit progresses
+	var usedCount int64
+	err := tx.Model(&benefit_progress.BenefitProgress{}).
+		Where("benefit_id = ?", levelBenefit.BenefitID).Count(&usedCount).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if usedCount != 0 {
+		return nil
+	}
+
+	var benefit benefits.Benefit
+	if err = tx.First(&benefit, levelBenefit.BenefitID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	polymorphicBenefit, err := benefits.NewPolymorphicBenefit(benefit.PolymorphicBenefitType)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = tx.Delete(polymorphicBenefit, benefit.PolymorphicBenefitID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = tx.Delete(&benefit).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// SyncTravePassSeasonProgresses brings progress of users in the running
+// season in line with its ladder after levels or requirements change.
+func SyncTravePassSeasonProgresses(tx *gorm.DB, seasonID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var season TravePassSeason
+	if err := tx.First(&season, seasonID).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+	if !season.IsActive(time.Now()) {
+		return nil
+	}
+
+	var userIDs []int64
+	err := tx.Model(&TravePassSeasonProgress{}).
+		Where("season_id = ? AND archived_at IS NULL", seasonID).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	for _, userID := range userIDs {
+		if err = syncUserTravePassSeasonProgress(tx, userID, seasonID); err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+
+	return nil
+}
+
+// syncUserTravePassSeasonProgress recreates user requirement progresses
+// if the next level of user changed, and upgrades user level if
+// remaining requirements are completed.
+func syncUserTravePassSeasonProgress(tx *gorm.DB, userID, seasonID int64) error {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	level, err := GetTravePassLevel(tx, user.TravePassLevelID)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if level == nil || level.SeasonID != seasonID {
+		return nil
+	}
+
+	var progress TravePassSeasonProgress
+	err = tx.First(&progress, "user_id = ? AND season_id = ?", userID, seasonID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	nextLevel, err := level.NextLevel(tx)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var nextLevelID int64
+	if nextLevel != nil {
+		nextLevelID = nextLevel.ID
+	}
+
+	if progress.NextLevelID != nextLevelID {
+		if err = deleteUserRequirementProgresses(tx, userID); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if nextLevel != nil {
+			if err = CreateUserRequirementProgresses(tx, userID, nextLevel.ID); err != nil {
+				return logger.WrapError(err, "")
+			}
+		}
+	}
+
+	if err = saveUserTravePassSeasonProgress(tx, userID, level); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if _, err = CheckAndUpgradeTravePassLevel(tx, userID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package travepass
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoActiveSeason       = errors.New("there is no active season")
+	ErrPremiumUnavailable   = errors.New("premium pass is not available in the season")
+	ErrPremiumAlreadyBought = errors.New("premium pass is already bought")
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+)
+
+func isUserTravePassSeasonPremium(tx *gorm.DB, userID, seasonID int64) (bool, error) {
+	var premium bool
+	err := tx.Model(&TravePassSeasonProgress{}).Select("premium").
+		Where("user_id = ? AND season_id = ?", userID, seasonID).
+		Scan(&premium).Error
+	if err != nil {
+		return false, logger.WrapError(err, "")
+	}
+
+	return premium, nil
+}
+
+// BuyTravePassPremium buys premium pass of the active season from user
+// cash balance. Premium benefits of levels user already reached in
+// the season are given straight away.
+func BuyTravePassPremium(tx *gorm.DB, userID int64) (*TravePassSeasonProgress, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if err := EnsureUserTravePassSeason(tx, userID); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	season, err := GetActiveTravePassSeason(tx, time.Now())
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if season == nil {
+		return nil, ErrNoActiveSeason
+	}
+	if season.PremiumPriceRupee <= 0 {
+		return nil, ErrPremiumUnavailable
+	}
+
+	var user models.User
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var progress TravePassSeasonProgress
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&progress, "user_id = ? AND season_id = ?", userID, season.ID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	if progress.Premium {
+		return nil, ErrPremiumAlreadyBought
+	}
+
+	// Premium is paid with real money only
+	if user.BalanceRupee < season.PremiumPriceRupee {
+		return nil, ErrInsufficientBalance
+	}
+
+	user.BalanceRupee -= season.PremiumPriceRupee
+	if err = tx.Save(&user).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	now := time.Now()
+	progress.Premium = true
+	progress.PremiumAt = &now
+	if err = tx.Save(&progress).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	reachedLevelIDs, err := getUserTravePassReachedLevelIDs(tx, userID, season.ID)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	for _, levelID := range reachedLevelIDs {
+		if err = CreateUserBenefitProgresses(tx, userID, levelID, true); err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+	}
+
+	progress.Season = *season
+	return &progress, nil
+}
@@ -0,0 +1,105 @@
+package travepass
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TravePassReachedLevel records season level reached by user. Premium
+// benefits of reached levels are given when user buys premium pass.
+// SeenAt is set once reached level is shown to user.
+type TravePassReachedLevel struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement"`
+	UserID    int64      `gorm:"uniqueIndex:idx_trave_pass_reached_level_user_level;not null"`
+	LevelID   int64      `gorm:"uniqueIndex:idx_trave_pass_reached_level_user_level;not null"`
+	SeasonID  int64      `gorm:"index;not null"`
+	SeenAt    *time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// saveTravePassReachedLevel records level reached by user,
+// level reached again after ladder change is kept once.
+func saveTravePassReachedLevel(tx *gorm.DB, userID int64, level *TravePassLevel) error {
+	reachedLevel := TravePassReachedLevel{
+		UserID: userID, LevelID: level.ID, SeasonID: level.SeasonID}
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reachedLevel).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// getUserTravePassReachedLevelIDs returns ids of season levels
+// reached by user in order of reaching.
+func getUserTravePassReachedLevelIDs(tx *gorm.DB, userID, seasonID int64) ([]int64, error) {
+	var levelIDs []int64
+	err := tx.Model(&TravePassReachedLevel{}).
+		Where("user_id = ? AND season_id = ?", userID, seasonID).
+		Order("id").Pluck("level_id", &levelIDs).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return levelIDs, nil
+}
+
+// GetUnseenTravePassReachedLevels returns levels reached by user since
+// the previous call with their benefits, ordered by season and number.
+// Returned levels are marked seen.
+func GetUnseenTravePassReachedLevels(tx *gorm.DB, userID int64) ([]TravePassLevel, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var reachedLevels []TravePassReachedLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&reachedLevels, "user_id = ? AND seen_at IS NULL", userID).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	if len(reachedLevels) == 0 {
+		return nil, nil
+	}
+
+	reachedLevelIDs := make([]int64, 0, len(reachedLevels))
+	levelIDs := make([]int64, 0, len(reachedLevels))
+	for _, reachedLevel := range reachedLevels {
+		reachedLevelIDs = append(reachedLevelIDs, reachedLevel.ID)
+		levelIDs = append(levelIDs, reachedLevel.LevelID)
+	}
+
+	err = tx.Model(&TravePassReachedLevel{}).Where("id IN ?", reachedLevelIDs).
+		Update("seen_at", time.Now()).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	var levels []TravePassLevel
+	err = tx.Preload("Benefits.Benefit").Preload("Benefits", "premium = ?", false).
+		Preload("PremiumBenefits.Benefit").Preload("PremiumBenefits", "premium = ?", true).
+		Where("id IN ?", levelIDs).Order("season_id, number").Find(&levels).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	for levelIdx := range levels {
+		for benIdx := range levels[levelIdx].Benefits {
+			if err = levels[levelIdx].Benefits[benIdx].Benefit.PreloadPolymorphicBenefit(tx); err != nil {
+				return nil, logger.WrapError(err, "")
+			}
+		}
+
+		for benIdx := range levels[levelIdx].PremiumBenefits {
+			if err = levels[levelIdx].PremiumBenefits[benIdx].Benefit.PreloadPolymorphicBenefit(tx); err != nil {
+				return nil, logger.WrapError(err, "")
+			}
+		}
+	}
+
+	return levels, nil
+}
@@ -42,3 +42,16 @@ func CreateUserRequirementProgresses(tx *gorm.DB, userID, nextLevelID int64) err
 
 	return nil
 }
+
+// deleteUserRequirementProgresses deletes user progresses of trave pass
+// level requirements. Progresses of requirements used elsewhere are kept.
+func deleteUserRequirementProgresses(tx *gorm.DB, userID int64) error {
+	levelRequirementIDs := tx.Model(&TravePassLevelRequirement{}).Select("requirement_id")
+
+	err := requirement_progress.DeleteUserRequirementProgresses(tx, userID, levelRequirementIDs)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package travepass
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TravePassSeason is a time limited trave pass with its own level ladder.
+// Levels of the season are numbered from 0, level 0 is the starting
+// level without requirements and benefits. Zero PremiumPriceRupee
+// means premium pass can't be bought in the season.
+type TravePassSeason struct {
+	ID                int64            `gorm:"primaryKey;autoIncrement"`
+	Name              string           `gorm:"not null"`
+	StartsAt          time.Time        `gorm:"index;not null"`
+	EndsAt            time.Time        `gorm:"index;not null"`
+	PremiumPriceRupee float64          `gorm:"not null;default:0"`
+	Levels            []TravePassLevel `gorm:"foreignKey:SeasonID" json:",omitempty"`
+	CreatedAt         time.Time
+}
+
+// TravePassSeasonProgress is user progress in the season. Progress of
+// ended season is archived on rollover and keeps the reached level.
+// NextLevelID is the level which requirements user progresses track,
+// 0 when the last season level is reached. Premium is set when user
+// bought premium pass of the season.
+type TravePassSeasonProgress struct {
+	ID          int64           `gorm:"primaryKey;autoIncrement"`
+	UserID      int64           `gorm:"uniqueIndex:idx_trave_pass_season_progress_user_season;not null"`
+	SeasonID    int64           `gorm:"uniqueIndex:idx_trave_pass_season_progress_user_season;not null"`
+	Season      TravePassSeason `gorm:"foreignKey:SeasonID;constraint:OnDelete:CASCADE;"`
+	LevelID     int64           `gorm:"not null"`
+	LevelNumber int64           `gorm:"not null"`
+	NextLevelID int64
+	Premium     bool `gorm:"not null;default:false"`
+	PremiumAt   *time.Time
+	ArchivedAt  *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsActive reports whether season is running at given time.
+func (s *TravePassSeason) IsActive(at time.Time) bool {
+	return !at.Before(s.StartsAt) && at.Before(s.EndsAt)
+}
+
+// GetActiveTravePassSeason returns season running at given time,
+// the latest started one if seasons overlap. Returns nil if
+// there is no running season.
+func GetActiveTravePassSeason(tx *gorm.DB, at time.Time) (*TravePassSeason, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var season TravePassSeason
+	err := tx.Where("starts_at <= ? AND ends_at > ?", at, at).
+		Order("starts_at desc").First(&season).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return &season, nil
+}
+
+// CreateTravePassSeason creates season with levelsCount levels without
+// requirements and benefits, in addition to the starting level 0.
+// Existing seasons and user progress are kept.
+func CreateTravePassSeason(tx *gorm.DB, season *TravePassSeason, levelsCount int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	if !season.EndsAt.After(season.StartsAt) {
+		return errors.New("season should end after it starts")
+	}
+
+	if err := tx.Create(season).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	season.Levels = make([]TravePassLevel, 0, levelsCount+1)
+	for number := int64(0); number <= levelsCount; number++ {
+		level := TravePassLevel{SeasonID: season.ID, Number: number}
+		if err := tx.Create(&level).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+		season.Levels = append(season.Levels, level)
+	}
+
+	return nil
+}
+
+// GetUserTravePassSeasonProgresses returns user progress of all
+// seasons with seasons preloaded, the latest season first.
+func GetUserTravePassSeasonProgresses(tx *gorm.DB, userID int64) ([]TravePassSeasonProgress, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var progresses []TravePassSeasonProgress
+	err := tx.Preload("Season").Where("user_id = ?", userID).
+		Order("id desc").Find(&progresses).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return progresses, nil
+}
+
+// EnsureUserTravePassSeason moves user to the active season. If user
+// level belongs to another season, progress of that season is archived
+// and user starts the active season from level 0. Does nothing if
+// there is no active season.
+func EnsureUserTravePassSeason(tx *gorm.DB, userID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	season, err := GetActiveTravePassSeason(tx, time.Now())
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if season == nil {
+		return nil
+	}
+
+	var user models.User
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	level, err := GetTravePassLevel(tx, user.TravePassLevelID)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if level != nil && level.SeasonID == season.ID {
+		// Users who reached level before seasons tracking
+		// have no season progress yet
+		return saveUserTravePassSeasonProgress(tx, userID, level)
+	}
+
+	return startUserTravePassSeason(tx, &user, season)
+}
+
+// StartUserTravePass puts new user on the starting level
+// of the active season.
+func StartUserTravePass(tx *gorm.DB, userID int64) error {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	season, err := GetActiveTravePassSeason(tx, time.Now())
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if season == nil {
+		return nil
+	}
+
+	var user models.User
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return startUserTravePassSeason(tx, &user, season)
+}
+
+func startUserTravePassSeason(tx *gorm.DB, user *models.User, season *TravePassSeason) error {
+	err := tx.Model(&TravePassSeasonProgress{}).
+		Where("user_id = ? AND season_id <> ? AND archived_at IS NULL", user.ID, season.ID).
+		Update("archived_at", time.Now()).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	// Uncompleted requirements of the previous season
+	if err = deleteUserRequirementProgresses(tx, user.ID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var startLevel TravePassLevel
+	err = tx.First(&startLevel, "season_id = ? AND number = 0", season.ID).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	user.TravePassLevelID = startLevel.ID
+	if err = tx.Save(user).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if err = saveUserTravePassSeasonProgress(tx, user.ID, &startLevel); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	nextLevel, err := startLevel.NextLevel(tx)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	if nextLevel == nil {
+		return nil
+	}
+
+	if err = CreateUserRequirementProgresses(tx, user.ID, nextLevel.ID); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// saveUserTravePassSeasonProgress sets reached level in
+// user progress of the level season.
+func saveUserTravePassSeasonProgress(tx *gorm.DB, userID int64, level *TravePassLevel) error {
+	progress := TravePassSeasonProgress{UserID: userID, SeasonID: level.SeasonID}
+	err := tx.Where(&progress).FirstOrInit(&progress).Error
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	nextLevel, err := level.NextLevel(tx)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	var nextLevelID int64
+	if nextLevel != nil {
+		nextLevelID = nextLevel.ID
+	}
+
+	if progress.ID != 0 && progress.LevelID == level.ID &&
+		progress.LevelNumber == level.Number && progress.NextLevelID == nextLevelID {
+		return nil
+	}
+
+	progress.LevelID = level.ID
+	progress.LevelNumber = level.Number
+	progress.NextLevelID = nextLevelID
+	progress.ArchivedAt = nil
+
+	if err = tx.Save(&progress).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	benefitExpiryInterval = time.Minute
+	benefitExpiryBatch    = 500
+	// Users are warned about benefits expiring this soon,
+	// benefits given for a shorter time aren't warned about
+	benefitExpiryNotifyBefore = 24 * time.Hour
+)
+
+var benefitExpiryNotificationClient = &http.Client{Timeout: 10 * time.Second}
+
+// BenefitExpiryNotificationBody is sent to BENEFIT_EXPIRY_NOTIFICATION_URL
+// before user benefit expires.
+type BenefitExpiryNotificationBody struct {
+	UserID      int64     `json:"userId"`
+	BenefitType string    `json:"benefitType"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// SuperviseBenefitExpiry restarts benefit expiry worker if it panics.
+func SuperviseBenefitExpiry() {
+	for {
+		logger.Info("Starting benefit expiry worker")
+
+		done := make(chan bool)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Benefit expiry worker panicked: %v", r)
+					done <- true
+				}
+			}()
+
+			StartBenefitExpiry()
+		}()
+
+		<-done
+
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// StartBenefitExpiry expires due benefit progresses, including ones
+// expired while server was down. Expiry is set for progresses given
+// before benefits expired.
+func StartBenefitExpiry() {
+	ticker := time.NewTicker(benefitExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := benefit_progress.SetMissingBenefitProgressesExpiry(nil); err != nil {
+			logger.Error("%v", err)
+		}
+
+		expireBenefitProgresses()
+
+		<-ticker.C
+	}
+}
+
+// SuperviseBenefitExpiryNotifications restarts benefit expiry
+// notifications worker if it panics. Worker isn't started if
+// notification url isn't configured.
+func SuperviseBenefitExpiryNotifications() {
+	url := os.Getenv("BENEFIT_EXPIRY_NOTIFICATION_URL")
+	if url == "" {
+		return
+	}
+
+	for {
+		logger.Info("Starting benefit expiry notifications worker")
+
+		done := make(chan bool)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Benefit expiry notifications worker panicked: %v", r)
+					done <- true
+				}
+			}()
+
+			StartBenefitExpiryNotifications(url)
+		}()
+
+		<-done
+
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// StartBenefitExpiryNotifications warns users about soon expiring
+// benefit progresses. Slow notification server doesn't delay expiry.
+func StartBenefitExpiryNotifications(url string) {
+	ticker := time.NewTicker(benefitExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		notifyBenefitProgressesExpiry(url)
+
+		<-ticker.C
+	}
+}
+
+func expireBenefitProgresses() {
+	benefitProgresses, err := benefit_progress.GetExpiredBenefitProgresses(nil, time.Now(), benefitExpiryBatch)
+	if err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	for i := range benefitProgresses {
+		if err = expireBenefitProgress(benefitProgresses[i].ID); err != nil {
+			logger.Error("Error expiring benefit progress %d: %v", benefitProgresses[i].ID, err)
+		}
+	}
+}
+
+// expireBenefitProgress expires benefit progress unless
+// it was used up since it was loaded.
+func expireBenefitProgress(benefitProgressID int64) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var benefitProgress benefit_progress.BenefitProgress
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&benefitProgress, benefitProgressID).Error
+		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if err = benefit_progress.ExpireBenefitProgress(tx, &benefitProgress); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		logger.Info("Benefit progress expired: ID=%d, UserID=%d, Type=%s",
+			benefitProgress.ID, benefitProgress.UserID,
+			benefitProgress.PolymorphicBenefitProgressType)
+
+		return nil
+	})
+}
+
+func notifyBenefitProgressesExpiry(url string) {
+	now := time.Now()
+	benefitProgresses, err := benefit_progress.GetBenefitProgressesToNotify(
+		nil, now, now.Add(benefitExpiryNotifyBefore), benefitExpiryNotifyBefore, benefitExpiryBatch)
+	if err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	for i := range benefitProgresses {
+		err = sendBenefitExpiryNotification(url, BenefitExpiryNotificationBody{
+			UserID:      benefitProgresses[i].UserID,
+			BenefitType: benefitProgresses[i].Benefit.PolymorphicBenefitType,
+			ExpiresAt:   benefitProgresses[i].ExpiresAt,
+		})
+		if err != nil {
+			// Retried on the next run
+			logger.Error("Error notifying user %d about benefit expiry: %v",
+				benefitProgresses[i].UserID, err)
+			continue
+		}
+
+		err = benefit_progress.SetBenefitProgressExpiryNotified(nil, &benefitProgresses[i])
+		if err != nil {
+			logger.Error("%v", err)
+		}
+	}
+}
+
+func sendBenefitExpiryNotification(url string, notification BenefitExpiryNotificationBody) error {
+	jsonData, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("error marshaling notification: %v", err)
+	}
+
+	resp, err := benefitExpiryNotificationClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error sending notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notification server returned non-200 status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
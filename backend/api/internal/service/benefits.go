@@ -6,11 +6,15 @@ import (
 	"BlessedApi/internal/models/benefits/benefit_progress"
 	"BlessedApi/pkg/logger"
 	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// GetUserBenefitsProgress returns user benefits which are not used up
+// or expired yet, soonest expiring first. ExpiresIn of each benefit
+// is seconds left before expiry.
 func GetUserBenefitsProgress(c *gin.Context) {
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
@@ -22,9 +26,12 @@ func GetUserBenefitsProgress(c *gin.Context) {
 	var benefitProgresses []benefit_progress.BenefitProgress
 	errBenefitsNotFound := errors.New("benefits not found")
 
+	now := time.Now()
+
 	err = db.DB.Transaction(func(tx *gorm.DB) error {
-		err = tx.Preload("Benefit").
-			Find(&benefitProgresses, "user_id = ?", userID).Error
+		// Expired progresses are removed by expiry worker
+		err = tx.Preload("Benefit").Order("expires_at").
+			Find(&benefitProgresses, "user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, now).Error
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
@@ -42,14 +49,17 @@ func GetUserBenefitsProgress(c *gin.Context) {
 			if err != nil {
 				return logger.WrapError(err, "")
 			}
+			benefitProgresses[i].SetExpiresIn(now)
 		}
 
 		return nil
 	})
 	if err != nil && errors.Is(err, errBenefitsNotFound) {
 		c.String(404, "[]")
+		return
 	} else if err != nil {
 		logger.Error("%v", err)
+		c.Status(500)
 		return
 	}
 
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const binaryBetHistoryDefaultPageSize = 20
+
+// BinaryBetHistoryInput is query of binary bets history. From and To
+// are unix milliseconds of bet opening.
+type BinaryBetHistoryInput struct {
+	Page      int                       `form:"page" validate:"omitempty,min=1"`
+	PageSize  int                       `form:"page_size" validate:"omitempty,min=1,max=100"`
+	Symbol    string                    `form:"symbol"`
+	Outcome   string                    `form:"outcome" validate:"omitempty,oneof=pending win loss push refund sold"`
+	Direction models.BinaryBetDirection `form:"direction" validate:"omitempty,oneof=up down"`
+	From      int64                     `form:"from" validate:"min=0"`
+	To        int64                     `form:"to" validate:"min=0"`
+}
+
+// GetUserBinaryBetHistory returns page of user binary bets, the newest first.
+func GetUserBinaryBetHistory(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var input BinaryBetHistoryInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.PageSize == 0 {
+		input.PageSize = binaryBetHistoryDefaultPageSize
+	}
+
+	filter := models.BinaryBetFilter{
+		Symbol:    input.Symbol,
+		Outcome:   input.Outcome,
+		Direction: input.Direction,
+	}
+	if input.From != 0 {
+		filter.From = time.UnixMilli(input.From)
+	}
+	if input.To != 0 {
+		filter.To = time.UnixMilli(input.To)
+	}
+
+	bets, total, err := models.GetUserBinaryBets(nil, userID, filter,
+		input.PageSize, (input.Page-1)*input.PageSize)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	betResults := make([]gin.H, 0, len(bets))
+	for _, bet := range bets {
+		betResults = append(betResults, binaryBetResult(&bet))
+	}
+
+	c.JSON(200, gin.H{
+		"bets":     betResults,
+		"page":     input.Page,
+		"pageSize": input.PageSize,
+		"total":    total,
+	})
+}
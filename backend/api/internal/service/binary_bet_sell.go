@@ -0,0 +1,273 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/exchange"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/pricefeed"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Quote can be used to sell bet during this time
+	binaryBetQuoteTTL = 5 * time.Second
+	// Bets can't be sold during the last seconds before expiration
+	binaryBetSellCutoff = 3 * time.Second
+)
+
+type BinaryBetSellInput struct {
+	QuoteID int64 `json:"quoteID" validate:"required"`
+}
+
+// binarySellValue prices open bet at current price assuming log-normal
+// price walk with instrument SellVolatility per second. Value is expected
+// payout of win and push minus instrument SellMargin.
+func binarySellValue(bet *models.BinaryBet, instrument *BinaryInstrument,
+	price float64, remaining time.Duration) float64 {
+	// Move against bet direction is a loss, smaller than
+	// MinWinMove in bet direction is a push
+	winPrice := bet.OpenPrice + bet.MinWinMove
+	if bet.Direction == models.BetDown {
+		winPrice = bet.OpenPrice - bet.MinWinMove
+	}
+
+	// Probability that price at expiration is beyond given one
+	// on the side of bet direction
+	beyond := func(target float64) float64 {
+		stdDev := instrument.SellVolatility * math.Sqrt(remaining.Seconds())
+		d := math.Log(price / target)
+		if bet.Direction == models.BetDown {
+			d = -d
+		}
+		if stdDev == 0 {
+			if d > 0 {
+				return 1
+			}
+			return 0
+		}
+		return 0.5 * math.Erfc(-d/stdDev/math.Sqrt2)
+	}
+
+	winProbability := beyond(winPrice)
+	pushProbability := beyond(bet.OpenPrice) - winProbability
+
+	value := bet.Amount*binaryBetPayoutMultiplier(bet)*winProbability +
+		bet.Amount*pushProbability
+	return math.Floor(value*(1-instrument.SellMargin)*100) / 100
+}
+
+// QuoteBinaryBet returns value user gets for closing open bet now.
+// Quote is valid for binaryBetQuoteTTL.
+func QuoteBinaryBet(c *gin.Context, klineStore *pricefeed.KlineStore) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	betID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid bet id"})
+		return
+	}
+
+	var bet models.BinaryBet
+	err = db.DB.First(&bet, "id = ? AND user_id = ?", betID, userID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "bet not found"})
+		return
+	} else if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if msg := binaryBetNotSellableReason(&bet, time.Now()); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	instrument, err := getBinaryInstrument(bet.Symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "bet instrument can't be sold"})
+		return
+	}
+
+	if !isBinaryFeedHealthy(bet.Symbol) {
+		c.JSON(503, gin.H{"error": "price feed is unavailable, bets are suspended"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	kline, err := klineStore.Latest(ctx, bet.Symbol)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	now := time.Now()
+	quote := models.BinaryBetSellQuote{
+		BetID:     bet.ID,
+		UserID:    userID,
+		Price:     kline.Close,
+		Value:     binarySellValue(&bet, instrument, kline.Close, bet.ExpiresAt.Sub(now)),
+		ExpiresAt: now.Add(binaryBetQuoteTTL),
+	}
+
+	if err := db.DB.Create(&quote).Error; err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, quote)
+}
+
+// SellBinaryBet closes open bet at the value of not expired quote.
+// Sold value is paid to balances in proportion to the stake.
+func SellBinaryBet(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	betID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid bet id"})
+		return
+	}
+
+	var input BinaryBetSellInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	errBetNotFound := errors.New("bet not found")
+	errQuoteExpired := errors.New("quote is expired or already used")
+	var errNotSellable error
+	var bet models.BinaryBet
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock bet against concurrent settlement
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&bet, "id = ? AND user_id = ?", betID, userID).Error
+		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+			return errBetNotFound
+		} else if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		now := time.Now()
+		if msg := binaryBetNotSellableReason(&bet, now); msg != "" {
+			errNotSellable = errors.New(msg)
+			return errNotSellable
+		}
+
+		var quote models.BinaryBetSellQuote
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&quote, "id = ? AND bet_id = ? AND user_id = ?", input.QuoteID, bet.ID, userID).Error
+		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+			return errQuoteExpired
+		} else if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if quote.Used || now.After(quote.ExpiresAt) {
+			return errQuoteExpired
+		}
+
+		quote.Used = true
+		if err := tx.Save(&quote).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		bet.Outcome = models.BinaryBetSold
+		bet.ClosePrice = quote.Price
+		bet.Payout = quote.Value
+		bet.Settled = true
+		bet.SettledAt = &now
+		if err := tx.Save(&bet).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", bet.UserID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		cashAmount := quote.Value * bet.FromCashBalance / bet.Amount
+		bonusAmount := quote.Value * bet.FromBonusBalance / bet.Amount
+		err = exchange.UpdateUserBalances(tx, &user, cashAmount, bonusAmount, false)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		// Only bet sold at profit counts as won
+		err = events.Publish(tx, events.BetSettled{
+			UserID:          bet.UserID,
+			GameID:          requirements.BinaryOptionGameID,
+			AmountRupee:     bet.Amount,
+			FromCashRupee:   bet.FromCashBalance,
+			Won:             quote.Value > bet.Amount,
+			PayoutRupee:     quote.Value,
+			CashPayoutRupee: cashAmount,
+			IsBenefitBet:    bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil && errors.Is(err, errBetNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	} else if err != nil && (errors.Is(err, errQuoteExpired) ||
+		(errNotSellable != nil && errors.Is(err, errNotSellable))) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, bet)
+}
+
+// binaryBetNotSellableReason returns why bet can't be sold
+// at the moment or empty string if it can.
+func binaryBetNotSellableReason(bet *models.BinaryBet, now time.Time) string {
+	switch {
+	case bet.Settled || bet.Outcome != "":
+		return "bet is already closed"
+	case bet.IsBenefitBet:
+		return "free bets can't be sold"
+	case bet.ExpiresAt.Sub(now) < binaryBetSellCutoff:
+		return "bet is too close to expiration to be sold"
+	}
+	return ""
+}
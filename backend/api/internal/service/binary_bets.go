@@ -5,31 +5,34 @@ import (
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
 	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/exchange"
-	"BlessedApi/internal/models/requirements/requirement_progress"
-	"BlessedApi/internal/models/travepass"
-	"BlessedApi/pkg/binance"
+	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
-	"BlessedApi/pkg/redis"
+	"BlessedApi/pkg/pricefeed"
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"sort"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// BinaryBetInput defines binary option bet. Empty Symbol stands
+// for the default instrument, Duration must be allowed by instrument.
 type BinaryBetInput struct {
+	Symbol    string                    `json:"symbol"`
 	Amount    float64                   `json:"amount" validate:"required,gt=0"`
-	Duration  int64                     `json:"duration" validate:"required,min=10,max=600"`
+	Duration  int64                     `json:"duration" validate:"required,min=1"`
 	Direction models.BinaryBetDirection `json:"direction" validate:"required,oneof=up down"`
 }
 
-const WinMultiplier = 1.8 // 80% profit, shoudl adjust lates
+// Payout multiplier of bets placed before instruments were introduced
+const WinMultiplier = 1.8
 
 var validate *validator.Validate
 
@@ -37,7 +40,7 @@ func init() {
 	validate = validator.New()
 }
 
-func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
+func PlaceBinaryBet(c *gin.Context, klineStore *pricefeed.KlineStore) {
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
 		logger.Error("%v", err)
@@ -56,6 +59,23 @@ func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
 		return
 	}
 
+	instrument, err := getBinaryInstrument(input.Symbol)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !instrument.isDurationAllowed(input.Duration) {
+		c.JSON(400, gin.H{"error": "duration is not allowed for this instrument",
+			"durations": instrument.Durations})
+		return
+	}
+
+	if !isBinaryFeedHealthy(instrument.Symbol) {
+		c.JSON(503, gin.H{"error": "price feed is unavailable, bets are suspended"})
+		return
+	}
+
 	hasActiveBet, err := models.UserHasActiveBet(nil, userID)
 	if err != nil {
 		logger.Error("%v", err)
@@ -96,7 +116,7 @@ func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
 			betAmount = input.Amount
 		}
 
-		currentPrice, err := getCurrentPrice(redisService)
+		currentPrice, err := getCurrentPrice(klineStore, instrument.Symbol)
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
@@ -108,11 +128,17 @@ func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
 				tx, &user, input.Amount); err != nil {
 				return logger.WrapError(err, "")
 			}
+		} else {
+			// Free bet is paid out to bonus balance only
+			fromBonusBalance = benefitFreeDeposit
 		}
 
 		now := time.Now()
 		bet = models.BinaryBet{
 			UserID:           userID,
+			Symbol:           instrument.Symbol,
+			PayoutMultiplier: instrument.PayoutMultiplier,
+			MinWinMove:       instrument.minWinMove(),
 			Amount:           betAmount,
 			FromBonusBalance: fromBonusBalance,
 			FromCashBalance:  fromCashBalance,
@@ -127,16 +153,23 @@ func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
 			return logger.WrapError(err, "")
 		}
 
-		if err = models.MaintainLastTenBinaryOptionBets(tx, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-
 		if benefitFreeDeposit != 0 {
 			if err = applyBenefit(tx); err != nil {
 				return logger.WrapError(err, "")
 			}
 		}
 
+		err = events.Publish(tx, events.BetPlaced{
+			UserID:        userID,
+			GameID:        requirements.BinaryOptionGameID,
+			AmountRupee:   bet.Amount,
+			FromCashRupee: bet.FromCashBalance,
+			IsBenefitBet:  bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
 		return nil
 	})
 	if err != nil && errors.Is(err, errInsufficientBalance) {
@@ -148,48 +181,118 @@ func PlaceBinaryBet(c *gin.Context, redisService *redis.RedisService) {
 		return
 	}
 
-	go scheduleBetSettlement(
-		bet.ID, time.Duration(input.Duration)*time.Second, redisService)
-
 	c.JSON(200, bet)
 }
 
-func scheduleBetSettlement(betID int64, duration time.Duration, redisService *redis.RedisService) {
-	time.Sleep(duration)
-	err := settleBet(betID, redisService)
-	if err != nil {
-		logger.Error("Error settling bet %d: %v", betID, err)
-		// In case of error, we just log it and ignore
-		return
+const (
+	binaryBetSettlementInterval = 500 * time.Millisecond
+	binaryBetSettlementBatch    = 100
+	// Time to wait for the expiration price, after that bet is refunded
+	binaryBetPriceWaitTimeout = 15 * time.Second
+)
+
+var errBinaryBetPriceNotReady = errors.New("expiration price is not ready yet")
+
+// SuperviseBinaryBetSettlement restarts binary bets settlement
+// scheduler if it panics.
+func SuperviseBinaryBetSettlement(klineStore *pricefeed.KlineStore) {
+	for {
+		logger.Info("Starting binary bets settlement scheduler")
+
+		done := make(chan bool)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Binary bets settlement scheduler panicked: %v", r)
+					done <- true
+				}
+			}()
+
+			StartBinaryBetSettlement(klineStore)
+		}()
+
+		<-done
+
+		time.Sleep(5 * time.Second)
 	}
 }
 
-func settleBet(betID int64, redisService *redis.RedisService) error {
-	var bet models.BinaryBet
-	if err := db.DB.First(&bet, betID).Error; err != nil {
-		return logger.WrapError(err, "")
-	}
+// StartBinaryBetSettlement settles all due bets, including bets
+// expired while server was down, and then polls for new due bets.
+func StartBinaryBetSettlement(klineStore *pricefeed.KlineStore) {
+	ticker := time.NewTicker(binaryBetSettlementInterval)
+	defer ticker.Stop()
 
-	currentPrice, err := getCurrentPrice(redisService)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
+	for {
+		bets, err := models.GetDueBinaryBets(nil, time.Now(), binaryBetSettlementBatch)
+		if err != nil {
+			logger.Error("%v", err)
+		}
 
-	bet.ClosePrice = currentPrice
+		for _, bet := range bets {
+			err := settleBet(bet.ID, klineStore)
+			if err != nil && !errors.Is(err, errBinaryBetPriceNotReady) {
+				logger.Error("Error settling bet %d: %v", bet.ID, err)
+			}
+		}
 
-	var payout float64
-	if (bet.Direction == models.BetUp && bet.ClosePrice > bet.OpenPrice) ||
-		(bet.Direction == models.BetDown && bet.ClosePrice < bet.OpenPrice) {
-		bet.Outcome = "win"
-		payout = bet.Amount * WinMultiplier
-	} else {
-		bet.Outcome = "loss"
-		payout = 0
+		<-ticker.C
 	}
+}
 
-	bet.Payout = payout
+// settleBet settles bet by the price at its ExpiresAt. Bet is locked
+// and skipped if it's already settled. If the price is missing for
+// binaryBetPriceWaitTimeout after expiration or bet expired inside
+// price feed gap, stake is refunded.
+// Stake is also refunded on push, see BinaryInstrument.
+func settleBet(betID int64, klineStore *pricefeed.KlineStore) error {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var bet models.BinaryBet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&bet, betID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
 
-	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if bet.Settled || bet.Outcome != "" {
+			return nil
+		}
+
+		inGap, err := models.IsInPriceFeedGap(tx, bet.Symbol, bet.ExpiresAt)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+		if inGap {
+			logger.Warn("Bet %d expired inside price feed gap, refunding", bet.ID)
+			return refundBinaryBet(tx, &bet, models.BinaryBetRefund)
+		}
+
+		closePrice, err := getPriceAt(tx, klineStore, bet.Symbol, bet.ExpiresAt)
+		if err != nil && errors.Is(err, errBinaryBetPriceNotReady) {
+			if time.Since(bet.ExpiresAt) < binaryBetPriceWaitTimeout {
+				return errBinaryBetPriceNotReady
+			}
+			logger.Warn("No price for bet %d expired at %v, refunding", bet.ID, bet.ExpiresAt)
+			return refundBinaryBet(tx, &bet, models.BinaryBetRefund)
+		} else if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		bet.ClosePrice = closePrice
+
+		payoutMultiplier := binaryBetPayoutMultiplier(&bet)
+
+		outcome := binaryBetOutcome(&bet)
+		if outcome == models.BinaryBetPush {
+			return refundBinaryBet(tx, &bet, models.BinaryBetPush)
+		}
+
+		bet.Outcome = outcome
+		bet.Payout = 0
+		if outcome == models.BinaryBetWin {
+			bet.Payout = bet.Amount * payoutMultiplier
+		}
+
+		bet.MarkSettled(time.Now())
 		if err := tx.Save(&bet).Error; err != nil {
 			return logger.WrapError(err, "")
 		}
@@ -200,64 +303,113 @@ func settleBet(betID int64, redisService *redis.RedisService) error {
 		}
 
 		var bonusWinAmount, cashWinAmount float64
-		if bet.Outcome == "win" {
-			bonusWinAmount = bet.FromBonusBalance * WinMultiplier
-			cashWinAmount = bet.FromCashBalance * WinMultiplier
+		if bet.Outcome == models.BinaryBetWin {
+			bonusWinAmount = bet.FromBonusBalance * payoutMultiplier
+			cashWinAmount = bet.FromCashBalance * payoutMultiplier
 		}
 
-		benefitWin := bet.Outcome == "win" && bet.IsBenefitBet
+		benefitWin := bet.Outcome == models.BinaryBetWin && bet.IsBenefitBet
 		err = exchange.UpdateUserBalances(tx, &user, cashWinAmount, bonusWinAmount, benefitWin)
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
-		
 
-		if !bet.IsBenefitBet {
-			if err = updateBinaryOptionTravePassLevelRequirements(tx, &bet, cashWinAmount); err != nil {
-				return logger.WrapError(err, "")
-			}
+		err = events.Publish(tx, events.BetSettled{
+			UserID:          bet.UserID,
+			GameID:          requirements.BinaryOptionGameID,
+			AmountRupee:     bet.Amount,
+			FromCashRupee:   bet.FromCashBalance,
+			Won:             bet.Outcome == models.BinaryBetWin,
+			PayoutRupee:     cashWinAmount + bonusWinAmount,
+			CashPayoutRupee: cashWinAmount,
+			IsBenefitBet:    bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
 		}
 
 		return nil
 	})
-	if err != nil {
+	if err != nil && errors.Is(err, errBinaryBetPriceNotReady) {
+		return err
+	} else if err != nil {
 		return logger.WrapError(err, "")
 	}
 
 	return nil
 }
 
-func updateBinaryOptionTravePassLevelRequirements(
-	tx *gorm.DB, bet *models.BinaryBet, cashWinAmount float64) error {
+func binaryBetPayoutMultiplier(bet *models.BinaryBet) float64 {
+	if bet.PayoutMultiplier == 0 {
+		return WinMultiplier
+	}
+	return bet.PayoutMultiplier
+}
+
+// binaryBetOutcome returns outcome of bet by the move from its open
+// price to the close price. Price tie or move in bet direction smaller
+// than MinWinMove is a push, any move against bet direction is a loss.
+func binaryBetOutcome(bet *models.BinaryBet) string {
+	move := bet.ClosePrice - bet.OpenPrice
+	if bet.Direction != models.BetUp {
+		move = -move
+	}
+
+	switch {
+	case move < 0:
+		return models.BinaryBetLoss
+	// Epsilon protects from float rounding of prices
+	case move == 0 || math.Abs(move)+1e-9 < bet.MinWinMove:
+		return models.BinaryBetPush
+	default:
+		return models.BinaryBetWin
+	}
+}
+
+// refundBinaryBet returns bet stake to balances it was paid from
+// and settles bet with given outcome. Free bet is given back as
+// free bet instead. Refunded bet is not published as settled.
+func refundBinaryBet(tx *gorm.DB, bet *models.BinaryBet, outcome string) error {
 	if tx == nil {
 		tx = db.DB
 	}
 
-	var requirementBODone, requirementTurnoverDone bool
-	requirementBODone, err := requirement_progress.UpdateRequirementProgressBinaryOptionIfRequired(
-		tx, bet.UserID, bet.FromCashBalance, cashWinAmount)
-	if err != nil {
+	bet.Outcome = outcome
+	bet.Payout = bet.Amount
+	bet.MarkSettled(time.Now())
+	if err := tx.Save(bet).Error; err != nil {
 		return logger.WrapError(err, "")
 	}
 
-	if bet.FromCashBalance > 0 {
-		requirementTurnoverDone, err = requirement_progress.UpdateRequirementProgressTurnoverIfRequired(
-			tx, bet.UserID, bet.FromCashBalance)
+	if bet.IsBenefitBet {
+		err := benefit_progress.RestoreFreeBinaryOptionBet(tx, bet.UserID, bet.Amount)
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
+		return nil
+	}
 
+	var user models.User
+	if err := tx.First(&user, "id = ?", bet.UserID).Error; err != nil {
+		return logger.WrapError(err, "")
 	}
 
-	if requirementBODone || requirementTurnoverDone {
-		if err = travepass.CheckAndUpgradeTravePassLevel(tx, bet.UserID); err != nil {
-			return logger.WrapError(err, "")
-		}
+	err := exchange.UpdateUserBalances(tx, &user,
+		bet.FromCashBalance, bet.FromBonusBalance, false)
+	if err != nil {
+		return logger.WrapError(err, "")
 	}
 
 	return nil
 }
 
+const (
+	binaryBetsRecentDefaultLimit = 10
+	binaryBetsRecentMaxLimit     = 50
+)
+
+// GetUserBetOutcome returns user balance and the latest bets,
+// number of bets is set by "limit" query parameter.
 func GetUserBetOutcome(c *gin.Context) {
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
@@ -273,11 +425,16 @@ func GetUserBetOutcome(c *gin.Context) {
 		return
 	}
 
-	var latestBets []models.BinaryBet
-	if err := db.DB.Where("user_id = ?", userID).
-		Order("created_at desc").
-		Limit(10).
-		Find(&latestBets).Error; err != nil {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit",
+		strconv.Itoa(binaryBetsRecentDefaultLimit)))
+	if err != nil || limit < 1 || limit > binaryBetsRecentMaxLimit {
+		c.JSON(400, gin.H{"error": "limit must be a number between 1 and " +
+			strconv.Itoa(binaryBetsRecentMaxLimit)})
+		return
+	}
+
+	latestBets, _, err := models.GetUserBinaryBets(nil, userID, models.BinaryBetFilter{}, limit, 0)
+	if err != nil {
 		logger.Error("%v", err)
 		c.Status(500)
 		return
@@ -285,18 +442,7 @@ func GetUserBetOutcome(c *gin.Context) {
 
 	betResults := make([]gin.H, 0, len(latestBets))
 	for _, bet := range latestBets {
-		betResult := gin.H{
-			"betID":      bet.ID,
-			"amount":     bet.Amount,
-			"direction":  bet.Direction,
-			"openedAt":   bet.OpenedAt,
-			"expiresAt":  bet.ExpiresAt,
-			"openPrice":  bet.OpenPrice,
-			"closePrice": bet.ClosePrice,
-			"outcome":    bet.Outcome,
-			"payout":     bet.Payout,
-		}
-		betResults = append(betResults, betResult)
+		betResults = append(betResults, binaryBetResult(&bet))
 	}
 
 	c.JSON(200, gin.H{
@@ -305,6 +451,22 @@ func GetUserBetOutcome(c *gin.Context) {
 	})
 }
 
+func binaryBetResult(bet *models.BinaryBet) gin.H {
+	return gin.H{
+		"betID":      bet.ID,
+		"symbol":     bet.Symbol,
+		"amount":     bet.Amount,
+		"direction":  bet.Direction,
+		"openedAt":   bet.OpenedAt,
+		"expiresAt":  bet.ExpiresAt,
+		"openPrice":  bet.OpenPrice,
+		"closePrice": bet.ClosePrice,
+		"outcome":    bet.Outcome,
+		"payout":     bet.Payout,
+		"settledAt":  bet.SettledAt,
+	}
+}
+
 func GetUserFreeBinaryOptionBets(c *gin.Context) {
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
@@ -328,11 +490,11 @@ func GetUserFreeBinaryOptionBets(c *gin.Context) {
 	c.JSON(200, *benefitProgressBOs)
 }
 
-func getCurrentPrice(redisService *redis.RedisService) (float64, error) {
+func getCurrentPrice(klineStore *pricefeed.KlineStore, symbol string) (float64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	latestKline, err := getLatestKline(ctx, redisService)
+	latestKline, err := klineStore.Latest(ctx, symbol)
 	if err != nil {
 		return 0, logger.WrapError(err, "")
 	}
@@ -340,44 +502,35 @@ func getCurrentPrice(redisService *redis.RedisService) (float64, error) {
 	return latestKline.Close, nil
 }
 
-func getLatestKline(ctx context.Context, redisService *redis.RedisService) (binance.KlineData, error) {
-	keys, err := fetchSortedKeys(ctx, redisService)
-	if err != nil || len(keys) == 0 {
-		return binance.KlineData{}, logger.WrapError(err, "")
-	}
-
-	klineData, err := fetchSingleKlineData(ctx, keys[len(keys)-1], redisService)
-	if err != nil {
-		return binance.KlineData{}, logger.WrapError(err, "")
-	}
-
-	return klineData, nil
-}
-
-func fetchSortedKeys(ctx context.Context, redisService *redis.RedisService) ([]string, error) {
-	keys, err := redisService.Client().Keys(ctx, "binance_kline_*").Result()
-	if err != nil {
-		return nil, logger.WrapError(err, "")
-	}
-
-	if len(keys) == 0 {
-		return nil, logger.WrapError(fmt.Errorf("no kline data found in Redis"), "")
-	}
-
-	sort.Strings(keys)
-	return keys, nil
-}
+// getPriceAt returns close price of the kline containing moment t.
+// Kline missing in the store is looked up in the price archive.
+// Returns errBinaryBetPriceNotReady if kline is not stored or not closed yet.
+func getPriceAt(tx *gorm.DB, klineStore *pricefeed.KlineStore, symbol string, t time.Time) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-func fetchSingleKlineData(ctx context.Context, key string, redisService *redis.RedisService) (binance.KlineData, error) {
-	data, err := redisService.GetKey(ctx, key)
-	if err != nil {
-		return binance.KlineData{}, logger.WrapError(err, "")
+	openTime := t.Truncate(time.Second).UnixMilli()
+	kline, err := klineStore.At(ctx, symbol, openTime)
+	if err != nil && errors.Is(err, pricefeed.ErrKlineNotFound) {
+		archivedKlines, err := models.GetPriceKlines(tx, symbol, openTime, openTime)
+		if err != nil {
+			return 0, logger.WrapError(err, "")
+		}
+		if len(archivedKlines) == 0 {
+			return 0, errBinaryBetPriceNotReady
+		}
+		kline = pricefeed.KlineData{
+			OpenTime:  archivedKlines[0].OpenTime,
+			CloseTime: archivedKlines[0].CloseTime,
+			Close:     archivedKlines[0].Close,
+		}
+	} else if err != nil {
+		return 0, logger.WrapError(err, "")
 	}
 
-	var kline binance.KlineData
-	if err := json.Unmarshal([]byte(data), &kline); err != nil {
-		return binance.KlineData{}, logger.WrapError(err, "")
+	if time.Now().UnixMilli() <= kline.CloseTime {
+		return 0, errBinaryBetPriceNotReady
 	}
 
-	return kline, nil
+	return kline.Close, nil
 }
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BinaryCandleInterval struct {
+	Name     string
+	Duration time.Duration
+}
+
+// BinaryCandleIntervals are intervals of candles aggregated
+// from the price archive.
+var BinaryCandleIntervals = []BinaryCandleInterval{
+	{Name: "1m", Duration: time.Minute},
+	{Name: "5m", Duration: 5 * time.Minute},
+	{Name: "15m", Duration: 15 * time.Minute},
+	{Name: "1h", Duration: time.Hour},
+}
+
+const (
+	binaryCandlesDefaultCount = 300
+	binaryCandlesMaxCount     = 1000
+)
+
+func getBinaryCandleInterval(name string) (BinaryCandleInterval, bool) {
+	for _, interval := range BinaryCandleIntervals {
+		if interval.Name == name {
+			return interval, true
+		}
+	}
+	return BinaryCandleInterval{}, false
+}
+
+// GetBinaryCandles returns OHLCV candles of "symbol" and "interval"
+// query parameters, opened from "from" to "to" unix milliseconds.
+// By default returns the latest 300 candles, up to 1000 per request.
+func GetBinaryCandles(c *gin.Context) {
+	instrument, err := getBinaryInstrument(c.Query("symbol"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	interval, ok := getBinaryCandleInterval(c.DefaultQuery("interval", "1m"))
+	if !ok {
+		c.JSON(400, gin.H{"error": "interval must be one of 1m, 5m, 15m, 1h"})
+		return
+	}
+	intervalMs := interval.Duration.Milliseconds()
+
+	to := time.Now().UnixMilli()
+	if toQuery := c.Query("to"); toQuery != "" {
+		if to, err = strconv.ParseInt(toQuery, 10, 64); err != nil {
+			c.JSON(400, gin.H{"error": "to must be unix time in milliseconds"})
+			return
+		}
+	}
+
+	from := to - binaryCandlesDefaultCount*intervalMs
+	if fromQuery := c.Query("from"); fromQuery != "" {
+		if from, err = strconv.ParseInt(fromQuery, 10, 64); err != nil {
+			c.JSON(400, gin.H{"error": "from must be unix time in milliseconds"})
+			return
+		}
+	}
+
+	if from > to {
+		c.JSON(400, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	if (to-from)/intervalMs >= binaryCandlesMaxCount {
+		c.JSON(400, gin.H{"error": "time range exceeds " +
+			strconv.Itoa(binaryCandlesMaxCount) + " candles"})
+		return
+	}
+
+	candles, err := models.GetPriceCandles(nil, instrument.Symbol, interval.Name, from, to)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, candles)
+}
+
+// aggregateBinaryCandles updates candles of all intervals
+// containing klines opened from fromOpenTime to toOpenTime.
+func aggregateBinaryCandles(symbol string, fromOpenTime, toOpenTime int64) error {
+	for _, interval := range BinaryCandleIntervals {
+		err := models.AggregatePriceCandles(nil, symbol, interval.Name,
+			interval.Duration.Milliseconds(), fromOpenTime, toOpenTime)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/pricefeed"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	binaryFeedWatchdogInterval = time.Second
+	// Symbol feed is stale if its latest kline closed earlier,
+	// binary bets of stale symbol are suspended
+	binaryFeedStaleAfter = 5 * time.Second
+)
+
+// BinaryFeedStatus is a price feed health of the instrument.
+// GapStartedAt is set while price feed gap is ongoing.
+type BinaryFeedStatus struct {
+	Symbol       string     `json:"symbol"`
+	Healthy      bool       `json:"healthy"`
+	LastKlineAt  *time.Time `json:"lastKlineAt"`
+	GapStartedAt *time.Time `json:"gapStartedAt"`
+	CheckedAt    *time.Time `json:"checkedAt"`
+}
+
+var (
+	binaryFeedStatuses      = make(map[string]BinaryFeedStatus)
+	binaryFeedStatusesMutex sync.RWMutex
+)
+
+// GetBinaryFeedStatus returns price feed health of all instruments.
+func GetBinaryFeedStatus(c *gin.Context) {
+	statuses := make([]BinaryFeedStatus, 0, len(BinaryInstruments))
+	for _, instrument := range BinaryInstruments {
+		statuses = append(statuses, getBinaryFeedStatus(instrument.Symbol))
+	}
+
+	c.JSON(200, statuses)
+}
+
+// getBinaryFeedStatus returns the last watchdog check of symbol feed.
+// Feed is not healthy until the first check.
+func getBinaryFeedStatus(symbol string) BinaryFeedStatus {
+	binaryFeedStatusesMutex.RLock()
+	defer binaryFeedStatusesMutex.RUnlock()
+
+	status, ok := binaryFeedStatuses[symbol]
+	if !ok {
+		return BinaryFeedStatus{Symbol: symbol}
+	}
+	return status
+}
+
+func isBinaryFeedHealthy(symbol string) bool {
+	return getBinaryFeedStatus(symbol).Healthy
+}
+
+// SuperviseBinaryFeedWatchdog restarts price feed watchdog if it panics.
+func SuperviseBinaryFeedWatchdog(klineStore *pricefeed.KlineStore) {
+	for {
+		logger.Info("Starting binary options price feed watchdog")
+
+		done := make(chan bool)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Binary options price feed watchdog panicked: %v", r)
+					done <- true
+				}
+			}()
+
+			StartBinaryFeedWatchdog(klineStore)
+		}()
+
+		<-done
+
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// StartBinaryFeedWatchdog checks latest kline of every instrument
+// and records price feed gaps while klines are stale.
+func StartBinaryFeedWatchdog(klineStore *pricefeed.KlineStore) {
+	ticker := time.NewTicker(binaryFeedWatchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, instrument := range BinaryInstruments {
+			if err := checkBinaryFeed(klineStore, instrument.Symbol); err != nil {
+				logger.Error("%v", err)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+func checkBinaryFeed(klineStore *pricefeed.KlineStore, symbol string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	status := BinaryFeedStatus{Symbol: symbol, CheckedAt: &now}
+
+	// Missing klines and Redis errors both mean feed is unavailable
+	kline, err := klineStore.Latest(ctx, symbol)
+	if err == nil {
+		lastKlineAt := time.UnixMilli(kline.CloseTime)
+		status.LastKlineAt = &lastKlineAt
+		status.Healthy = now.Sub(lastKlineAt) <= binaryFeedStaleAfter
+	}
+
+	gap, err := models.GetOpenPriceFeedGap(nil, symbol)
+	if err != nil {
+		setBinaryFeedStatus(status)
+		return logger.WrapError(err, "")
+	}
+
+	switch {
+	case !status.Healthy && gap == nil:
+		gap = &models.PriceFeedGap{Symbol: symbol, StartedAt: now}
+		if status.LastKlineAt != nil {
+			gap.StartedAt = *status.LastKlineAt
+		}
+
+		if err := db.DB.Create(gap).Error; err != nil {
+			setBinaryFeedStatus(status)
+			return logger.WrapError(err, "")
+		}
+		logger.Warn("Price feed of %s is stale since %v", symbol, gap.StartedAt)
+
+	case status.Healthy && gap != nil:
+		endedAt := time.UnixMilli(kline.OpenTime)
+		gap.EndedAt = &endedAt
+		if err := db.DB.Save(gap).Error; err != nil {
+			setBinaryFeedStatus(status)
+			return logger.WrapError(err, "")
+		}
+		logger.Info("Price feed of %s recovered, gap %v - %v", symbol, gap.StartedAt, endedAt)
+		gap = nil
+	}
+
+	if gap != nil {
+		status.GapStartedAt = &gap.StartedAt
+	}
+	setBinaryFeedStatus(status)
+
+	return nil
+}
+
+func setBinaryFeedStatus(status BinaryFeedStatus) {
+	binaryFeedStatusesMutex.Lock()
+	defer binaryFeedStatusesMutex.Unlock()
+
+	binaryFeedStatuses[status.Symbol] = status
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"BlessedApi/internal/models"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BinaryInstrument configures an asset available for binary options.
+// Durations are allowed bet durations in seconds. Bet wins only if
+// price moved in its direction at least by MinWinTicks ticks of
+// TickSize, smaller move in its direction is a push and stake is
+// refunded, any move against it is a loss.
+// SellVolatility and SellMargin configure early close pricing,
+// see binarySellValue.
+type BinaryInstrument struct {
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
+	Durations        []int64 `json:"durations"`
+	PayoutMultiplier float64 `json:"payoutMultiplier"`
+	TickSize         float64 `json:"tickSize"`
+	MinWinTicks      int64   `json:"minWinTicks"`
+	SellVolatility   float64 `json:"-"`
+	SellMargin       float64 `json:"-"`
+}
+
+// BinaryInstruments is the list of assets available for binary options.
+// The first one is used by default.
+var BinaryInstruments = []BinaryInstrument{
+	{
+		Symbol:           models.DefaultBinaryBetSymbol,
+		Name:             "Bitcoin",
+		Durations:        binaryDurationRange(10, 600, 10),
+		PayoutMultiplier: 1.8,
+		TickSize:         0.01,
+		MinWinTicks:      1,
+		SellVolatility:   0.0002,
+		SellMargin:       0.1,
+	},
+	{
+		Symbol:           "ETHUSDT",
+		Name:             "Ethereum",
+		Durations:        []int64{10, 30, 60, 120, 300},
+		PayoutMultiplier: 1.8,
+		TickSize:         0.01,
+		MinWinTicks:      1,
+		SellVolatility:   0.00025,
+		SellMargin:       0.1,
+	},
+	{
+		Symbol:           "SOLUSDT",
+		Name:             "Solana",
+		Durations:        []int64{30, 60, 120, 300},
+		PayoutMultiplier: 1.75,
+		TickSize:         0.01,
+		MinWinTicks:      1,
+		SellVolatility:   0.0003,
+		SellMargin:       0.1,
+	},
+}
+
+func binaryDurationRange(from, to, step int64) []int64 {
+	var durations []int64
+	for d := from; d <= to; d += step {
+		durations = append(durations, d)
+	}
+	return durations
+}
+
+// BinaryInstrumentSymbols returns symbols of all instruments.
+func BinaryInstrumentSymbols() []string {
+	symbols := make([]string, 0, len(BinaryInstruments))
+	for _, instrument := range BinaryInstruments {
+		symbols = append(symbols, instrument.Symbol)
+	}
+	return symbols
+}
+
+// getBinaryInstrument returns instrument by symbol, empty
+// symbol stands for the default instrument.
+func getBinaryInstrument(symbol string) (*BinaryInstrument, error) {
+	if symbol == "" {
+		symbol = models.DefaultBinaryBetSymbol
+	}
+
+	for i := range BinaryInstruments {
+		if BinaryInstruments[i].Symbol == symbol {
+			return &BinaryInstruments[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown instrument %s", symbol)
+}
+
+// minWinMove returns minimal price move needed for a win.
+func (i *BinaryInstrument) minWinMove() float64 {
+	return float64(i.MinWinTicks) * i.TickSize
+}
+
+func (i *BinaryInstrument) isDurationAllowed(duration int64) bool {
+	for _, d := range i.Durations {
+		if d == duration {
+			return true
+		}
+	}
+	return false
+}
+
+func GetBinaryInstruments(c *gin.Context) {
+	c.JSON(200, BinaryInstruments)
+}
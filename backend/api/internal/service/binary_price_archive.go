@@ -0,0 +1,188 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/pricefeed"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// Klines are kept in Redis for 5 minutes, so archive
+	// must run much more often than that
+	binaryPriceArchiveInterval = 10 * time.Second
+	// Price window of bet audit includes klines this long
+	// before bet was opened and after it expired
+	binaryBetAuditMargin = 30 * time.Second
+)
+
+// BinaryBetAudit is the bet with archived prices around it. OpenKline
+// contains OpenedAt, bet open price is its close at that moment.
+// CloseKline contains ExpiresAt, its close is bet close price.
+type BinaryBetAudit struct {
+	Bet        models.BinaryBet    `json:"bet"`
+	OpenKline  *models.PriceKline  `json:"openKline"`
+	CloseKline *models.PriceKline  `json:"closeKline"`
+	Klines     []models.PriceKline `json:"klines"`
+}
+
+// GetBinaryBetAudit returns user bet with archived price window.
+func GetBinaryBetAudit(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	betID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid bet id"})
+		return
+	}
+
+	var audit BinaryBetAudit
+	err = db.DB.First(&audit.Bet, "id = ? AND user_id = ?", betID, userID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "bet not found"})
+		return
+	} else if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	audit.Klines, err = models.GetPriceKlines(nil, audit.Bet.Symbol,
+		audit.Bet.OpenedAt.Add(-binaryBetAuditMargin).UnixMilli(),
+		audit.Bet.ExpiresAt.Add(binaryBetAuditMargin).UnixMilli())
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	openTime := audit.Bet.OpenedAt.Truncate(time.Second).UnixMilli()
+	closeTime := audit.Bet.ExpiresAt.Truncate(time.Second).UnixMilli()
+	for i := range audit.Klines {
+		switch audit.Klines[i].OpenTime {
+		case openTime:
+			audit.OpenKline = &audit.Klines[i]
+		case closeTime:
+			audit.CloseKline = &audit.Klines[i]
+		}
+	}
+
+	c.JSON(200, audit)
+}
+
+// SuperviseBinaryPriceArchive restarts price archive if it panics.
+func SuperviseBinaryPriceArchive(klineStore *pricefeed.KlineStore) {
+	for {
+		logger.Info("Starting binary options price archive")
+
+		done := make(chan bool)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Binary options price archive panicked: %v", r)
+					done <- true
+				}
+			}()
+
+			StartBinaryPriceArchive(klineStore)
+		}()
+
+		<-done
+
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// StartBinaryPriceArchive copies closed klines of all instruments
+// from Redis to Postgres, continuing from the last archived kline,
+// and aggregates them into candles.
+func StartBinaryPriceArchive(klineStore *pricefeed.KlineStore) {
+	lastOpenTimes := make(map[string]int64, len(BinaryInstruments))
+	for _, instrument := range BinaryInstruments {
+		lastOpenTime, err := models.GetLastPriceKlineOpenTime(nil, instrument.Symbol)
+		if err != nil {
+			logger.Error("%v", err)
+		}
+		lastOpenTimes[instrument.Symbol] = lastOpenTime
+	}
+
+	ticker := time.NewTicker(binaryPriceArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, instrument := range BinaryInstruments {
+			lastOpenTime, err := archiveBinaryPriceKlines(
+				klineStore, instrument.Symbol, lastOpenTimes[instrument.Symbol])
+			if err != nil {
+				logger.Error("%v", err)
+				continue
+			}
+			lastOpenTimes[instrument.Symbol] = lastOpenTime
+		}
+
+		<-ticker.C
+	}
+}
+
+// archiveBinaryPriceKlines archives closed symbol klines opened after
+// given open time and returns open time of the last archived one.
+func archiveBinaryPriceKlines(klineStore *pricefeed.KlineStore, symbol string, after int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UnixMilli()
+	klines, err := klineStore.Range(ctx, symbol, after+1, now)
+	if err != nil {
+		return after, logger.WrapError(err, "")
+	}
+
+	archived := make([]models.PriceKline, 0, len(klines))
+	for _, kline := range klines {
+		// Kline is updated until it's closed
+		if kline.CloseTime >= now {
+			break
+		}
+
+		archived = append(archived, models.PriceKline{
+			Symbol:         kline.Symbol,
+			OpenTime:       kline.OpenTime,
+			CloseTime:      kline.CloseTime,
+			Open:           kline.Open,
+			High:           kline.High,
+			Low:            kline.Low,
+			Close:          kline.Close,
+			Volume:         kline.Volume,
+			NumberOfTrades: kline.NumberOfTrades,
+		})
+	}
+
+	if err := models.SavePriceKlines(nil, archived); err != nil {
+		return after, logger.WrapError(err, "")
+	}
+
+	if len(archived) == 0 {
+		return after, nil
+	}
+
+	lastOpenTime := archived[len(archived)-1].OpenTime
+	if err := aggregateBinaryCandles(symbol, archived[0].OpenTime, lastOpenTime); err != nil {
+		// Klines are archived again with the next run, so that
+		// their candles are recalculated as well
+		return after, logger.WrapError(err, "")
+	}
+
+	return lastOpenTime, nil
+}
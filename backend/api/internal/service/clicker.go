@@ -5,8 +5,7 @@ import (
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
 	"BlessedApi/internal/models/benefits/benefit_progress"
-	"BlessedApi/internal/models/requirements/requirement_progress"
-	"BlessedApi/internal/models/travepass"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/pkg/logger"
 	"errors"
 	"math"
@@ -102,7 +101,8 @@ func AddClicks(c *gin.Context) {
 			return logger.WrapError(err, "")
 		}
 
-		if err = updateClickerTravePassLevelRequirements(tx, &user, addedClicksCount); err != nil {
+		if err = events.Publish(tx, events.ClicksAdded{
+			UserID: user.ID, ClicksCount: addedClicksCount}); err != nil {
 			return logger.WrapError(err, "")
 		}
 
@@ -128,26 +128,6 @@ func AddClicks(c *gin.Context) {
 	}
 }
 
-func updateClickerTravePassLevelRequirements(
-	tx *gorm.DB, user *models.User, addedClicksCount int) error {
-	if tx == nil {
-		tx = db.DB
-	}
-
-	requirementClickerDone, err := requirement_progress.UpdateRequirementProgressClickerIfRequired(tx, user.ID, addedClicksCount)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-
-	if requirementClickerDone {
-		if err = travepass.CheckAndUpgradeTravePassLevel(tx, user.ID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	}
-
-	return nil
-}
-
 func GetUserCurrentBiPerClickCost(c *gin.Context) {
 	// get user id
 	userID, err := middleware.GetUserIDFromGinContext(c)
@@ -4,7 +4,10 @@ import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/exchange"
+	"BlessedApi/internal/models/requirements"
 	"BlessedApi/pkg/logger"
 	"context"
 	"errors"
@@ -100,6 +103,9 @@ func StartCrashGame() {
 		// Открываем окно для ставок
 		openCrashGameBetting()
 
+		// Ставки по автоставкам пользователей
+		placeCrashGameAutoBets(currentCrashGame)
+
 		// Ждем установленное время для приема ставок
 		for elapsedTime := time.Duration(0); elapsedTime < crashGameInterval; elapsedTime += time.Second {
 			if elapsedTime == crashGameBettingWindow {
@@ -375,7 +381,6 @@ func PlaceCrashGameBet(c *gin.Context) {
 		}
 	}
 
-	errInsufficientBalance := errors.New("insufficient balance")
 	errExistingBet := errors.New("user already has an active bet for this game")
 
 	err = db.DB.Transaction(func(tx *gorm.DB) error {
@@ -389,39 +394,21 @@ func PlaceCrashGameBet(c *gin.Context) {
 			return logger.WrapError(err, "")
 		}
 
-		var user models.User
-		if err := tx.First(&user, userID).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
-
-		bet := models.CrashGameBet{
-			UserID:            userID,
-			CrashGameID:       currentCrashGame.ID,
-			CashOutMultiplier: input.CashOutMultiplier,
-			Status:            "active",
-		}
-
-		bonusBalance, err := exchange.GetUserExchangedBalanceAmount(tx, user.ID)
+		bet, err := newBenefitCrashGameBet(tx, userID, currentCrashGame.ID, input.CashOutMultiplier)
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
 
-		if user.BalanceRupee+bonusBalance < input.Amount {
-			logger.Warn("User %d has insufficient balance: has %.2f, needs %.2f", userID, user.BalanceRupee+bonusBalance, input.Amount)
-			return errInsufficientBalance
-		}
-
-		fromCashBalance, fromBonusBalance, err := exchange.UseExchangeBalancePayment(tx, &user, input.Amount)
-		if err != nil {
-			return logger.WrapError(err, "")
+		if !bet.IsBenefitBet {
+			bet, err = newFundedCrashGameBet(tx, userID, currentCrashGame.ID,
+				input.Amount, input.CashOutMultiplier)
+			if err != nil {
+				return err
+			}
 		}
 
-		bet.Amount = fromCashBalance + fromBonusBalance
-		bet.FromBonusBalance = fromBonusBalance
-		bet.FromCashBalance = fromCashBalance
-
 		// Особая обработка для бэкдоров - устанавливаем точное значение ставки
-		if isBackdoor {
+		if isBackdoor && !bet.IsBenefitBet {
 			// Критические бэкдоры требуют абсолютно точного значения
 			if backdoorType == "538" {
 				bet.Amount = 538.0
@@ -456,6 +443,17 @@ func PlaceCrashGameBet(c *gin.Context) {
 			return logger.WrapError(err, "")
 		}
 
+		err = events.Publish(tx, events.BetPlaced{
+			UserID:        bet.UserID,
+			GameID:        requirements.CrashGameID,
+			AmountRupee:   bet.Amount,
+			FromCashRupee: bet.FromCashBalance,
+			IsBenefitBet:  bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
 		logger.Info("Bet created successfully: ID=%d, UserID=%d, Amount=%.4f, CashOutMultiplier=%.2f, GameID=%d",
 			bet.ID, bet.UserID, bet.Amount, bet.CashOutMultiplier, bet.CrashGameID)
 
@@ -466,7 +464,7 @@ func PlaceCrashGameBet(c *gin.Context) {
 
 	if err != nil {
 		switch {
-		case errors.Is(err, errInsufficientBalance):
+		case errors.Is(err, ErrInsufficientBalance):
 			c.JSON(402, gin.H{"error": "Insufficient balance"})
 		case errors.Is(err, errExistingBet):
 			c.JSON(400, gin.H{"error": "You already have an active bet for this game"})
@@ -578,6 +576,74 @@ func ManualCashout(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "manual cashout successful", "multiplier": currentMultiplier})
 }
 
+// newFundedCrashGameBet charges amount from user balances and returns
+// not yet created active bet for given game. Returns ErrInsufficientBalance
+// if user can't afford the bet.
+func newFundedCrashGameBet(tx *gorm.DB, userID, gameID int64, amount, cashOutMultiplier float64) (models.CrashGameBet, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return models.CrashGameBet{}, logger.WrapError(err, "")
+	}
+
+	bonusBalance, err := exchange.GetUserExchangedBalanceAmount(tx, user.ID)
+	if err != nil {
+		return models.CrashGameBet{}, logger.WrapError(err, "")
+	}
+
+	if user.BalanceRupee+bonusBalance < amount {
+		logger.Warn("User %d has insufficient balance: has %.2f, needs %.2f", userID, user.BalanceRupee+bonusBalance, amount)
+		return models.CrashGameBet{}, ErrInsufficientBalance
+	}
+
+	fromCashBalance, fromBonusBalance, err := exchange.UseExchangeBalancePayment(tx, &user, amount)
+	if err != nil {
+		return models.CrashGameBet{}, logger.WrapError(err, "")
+	}
+
+	return models.CrashGameBet{
+		UserID:            userID,
+		CrashGameID:       gameID,
+		CashOutMultiplier: cashOutMultiplier,
+		Status:            "active",
+		Amount:            fromCashBalance + fromBonusBalance,
+		FromBonusBalance:  fromBonusBalance,
+		FromCashBalance:   fromCashBalance,
+	}, nil
+}
+
+// newBenefitCrashGameBet returns not yet created active free bet for given
+// game if user has crash game free bets, otherwise returns bet with
+// IsBenefitBet unset. Used free bet is charged from user benefits.
+func newBenefitCrashGameBet(tx *gorm.DB, userID, gameID int64, cashOutMultiplier float64) (models.CrashGameBet, error) {
+	benefitFreeDeposit, applyBenefit, err := benefit_progress.UseFreeMiniGameBetIfAvailable(
+		tx, userID, requirements.CrashGameID)
+	if err != nil {
+		return models.CrashGameBet{}, logger.WrapError(err, "")
+	}
+
+	if benefitFreeDeposit == 0 {
+		return models.CrashGameBet{}, nil
+	}
+
+	if err = applyBenefit(tx); err != nil {
+		return models.CrashGameBet{}, logger.WrapError(err, "")
+	}
+
+	return models.CrashGameBet{
+		UserID:            userID,
+		CrashGameID:       gameID,
+		CashOutMultiplier: cashOutMultiplier,
+		Status:            "active",
+		Amount:            benefitFreeDeposit,
+		FromBonusBalance:  benefitFreeDeposit,
+		IsBenefitBet:      true,
+	}, nil
+}
+
 // Bet must exists
 func crashGameCashout(tx *gorm.DB, bet *models.CrashGameBet, currentMultiplier float64) error {
 	if tx == nil {
@@ -601,19 +667,49 @@ func crashGameCashout(tx *gorm.DB, bet *models.CrashGameBet, currentMultiplier f
 	toCashBalance := bet.FromCashBalance * currentMultiplier
 	toBonusBalance := bet.FromBonusBalance * currentMultiplier
 
-	win := models.Winning{
-		UserID:    user.ID,
-		WinAmount: toCashBalance + toBonusBalance,
-	}
-
-	if err := tx.Create(&win).Error; err != nil {
-		return logger.WrapError(err, "Failed to record winning")
+	err := exchange.UpdateUserBalances(tx, &user, toCashBalance, toBonusBalance, bet.IsBenefitBet)
+	if err != nil {
+		return logger.WrapError(err, "failed to update user balances")
 	}
 
-	err := exchange.UpdateUserBalances(tx, &user, toCashBalance, toBonusBalance, false)
+	err = events.Publish(tx, events.BetSettled{
+		UserID:            bet.UserID,
+		GameID:            requirements.CrashGameID,
+		AmountRupee:       bet.Amount,
+		FromCashRupee:     bet.FromCashBalance,
+		Won:               true,
+		PayoutRupee:       toCashBalance + toBonusBalance,
+		CashPayoutRupee:   toCashBalance,
+		CashOutMultiplier: currentMultiplier,
+		IsBenefitBet:      bet.IsBenefitBet,
+	})
 	if err != nil {
-		return logger.WrapError(err, "failed to update user balances")
+		return logger.WrapError(err, "")
 	}
 
 	return nil
 }
+
+// crashGameLose marks active bet lost and publishes its settlement.
+func crashGameLose(bet *models.CrashGameBet) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		bet.Status = "lost"
+		if err := tx.Save(bet).Error; err != nil {
+			return logger.WrapError(err, "failed to update bet")
+		}
+
+		err := events.Publish(tx, events.BetSettled{
+			UserID:        bet.UserID,
+			GameID:        requirements.CrashGameID,
+			AmountRupee:   bet.Amount,
+			FromCashRupee: bet.FromCashBalance,
+			Won:           false,
+			IsBenefitBet:  bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+}
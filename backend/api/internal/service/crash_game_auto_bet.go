@@ -0,0 +1,257 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CrashGameAutoBetInput struct {
+	BaseAmount            float64                       `json:"BaseAmount" validate:"required,min=1"`
+	CashOutMultiplier     float64                       `json:"CashOutMultiplier" validate:"required,gt=1"`
+	OnWinAction           models.CrashGameAutoBetAction `json:"OnWinAction" validate:"omitempty,oneof=reset increase"`
+	OnWinIncreasePercent  float64                       `json:"OnWinIncreasePercent" validate:"required_if=OnWinAction increase,min=0,max=1000"`
+	OnLossAction          models.CrashGameAutoBetAction `json:"OnLossAction" validate:"omitempty,oneof=reset increase"`
+	OnLossIncreasePercent float64                       `json:"OnLossIncreasePercent" validate:"required_if=OnLossAction increase,min=0,max=1000"`
+	StopOnProfit          float64                       `json:"StopOnProfit" validate:"min=0"`
+	StopOnLoss            float64                       `json:"StopOnLoss" validate:"min=0"`
+	MaxRounds             int                           `json:"MaxRounds" validate:"min=0"`
+}
+
+// GetCrashGameAutoBet returns user auto-bet plan, including stopped one,
+// so user can see why plan was stopped.
+func GetCrashGameAutoBet(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var autoBet models.CrashGameAutoBet
+	err = db.DB.First(&autoBet, "user_id = ?", userID).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "auto-bet plan not found"})
+		return
+	} else if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, autoBet)
+}
+
+// SetCrashGameAutoBet creates new user auto-bet plan or replaces existing.
+// Plan starts with the next betting window.
+func SetCrashGameAutoBet(c *gin.Context) {
+	var input CrashGameAutoBetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.OnWinAction == "" {
+		input.OnWinAction = models.CrashGameAutoBetReset
+	}
+	if input.OnLossAction == "" {
+		input.OnLossAction = models.CrashGameAutoBetReset
+	}
+
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var autoBet models.CrashGameAutoBet
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&autoBet, "user_id = ?", userID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return logger.WrapError(err, "")
+		}
+
+		autoBet = models.CrashGameAutoBet{
+			ID:                    autoBet.ID,
+			UserID:                userID,
+			BaseAmount:            input.BaseAmount,
+			CurrentAmount:         input.BaseAmount,
+			CashOutMultiplier:     input.CashOutMultiplier,
+			OnWinAction:           input.OnWinAction,
+			OnWinIncreasePercent:  input.OnWinIncreasePercent,
+			OnLossAction:          input.OnLossAction,
+			OnLossIncreasePercent: input.OnLossIncreasePercent,
+			StopOnProfit:          input.StopOnProfit,
+			StopOnLoss:            input.StopOnLoss,
+			MaxRounds:             input.MaxRounds,
+			Status:                models.CrashGameAutoBetActive,
+			CreatedAt:             autoBet.CreatedAt,
+		}
+
+		if err := tx.Save(&autoBet).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, autoBet)
+}
+
+// CancelCrashGameAutoBet stops user auto-bet plan. Bet placed
+// in current round by the plan stays in game.
+func CancelCrashGameAutoBet(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	result := db.DB.Model(&models.CrashGameAutoBet{}).
+		Where("user_id = ? AND status = ?", userID, models.CrashGameAutoBetActive).
+		Updates(map[string]interface{}{
+			"status":      models.CrashGameAutoBetStopped,
+			"stop_reason": models.CrashGameAutoBetStopCancelled,
+		})
+	if result.Error != nil {
+		logger.Error("%v", result.Error)
+		c.Status(500)
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "active auto-bet plan not found"})
+		return
+	}
+
+	c.Status(200)
+}
+
+// placeCrashGameAutoBets places bets of all active auto-bet plans
+// into given game. Must be called while betting window is open.
+func placeCrashGameAutoBets(game *models.CrashGame) {
+	autoBets, err := models.GetActiveCrashGameAutoBets(nil)
+	if err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	for i := range autoBets {
+		if err := placeCrashGameAutoBet(&autoBets[i], game); err != nil {
+			logger.Error("Failed to place auto-bet for user %d: %v", autoBets[i].UserID, err)
+		}
+	}
+}
+
+func saveCrashGameAutoBet(tx *gorm.DB, autoBet *models.CrashGameAutoBet) error {
+	if err := tx.Save(autoBet).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+	return nil
+}
+
+func placeCrashGameAutoBet(autoBet *models.CrashGameAutoBet, game *models.CrashGame) error {
+	var placedBet *models.CrashGameBet
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// Plan could be cancelled or replaced since it was loaded
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(autoBet, autoBet.ID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if autoBet.Status != models.CrashGameAutoBetActive {
+			return nil
+		}
+
+		settled, err := autoBet.ApplyLastBetResult(tx)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if !settled {
+			// Previous plan bet is still in game, skip this round
+			return nil
+		}
+
+		if reason := autoBet.StopReasonIfReached(); reason != "" {
+			autoBet.Stop(reason)
+			return saveCrashGameAutoBet(tx, autoBet)
+		}
+
+		var existingBet models.CrashGameBet
+		err = tx.Where("user_id = ? AND crash_game_id = ? AND status = ?",
+			autoBet.UserID, game.ID, "active").First(&existingBet).Error
+		if err == nil {
+			// User placed bet by hand, keep plan progress untouched
+			return saveCrashGameAutoBet(tx, autoBet)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return logger.WrapError(err, "")
+		}
+
+		bet, err := newFundedCrashGameBet(tx, autoBet.UserID, game.ID,
+			autoBet.CurrentAmount, autoBet.CashOutMultiplier)
+		if err != nil && errors.Is(err, ErrInsufficientBalance) {
+			autoBet.Stop(models.CrashGameAutoBetStopInsufficientBalance)
+			return saveCrashGameAutoBet(tx, autoBet)
+		} else if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if err := tx.Create(&bet).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		err = events.Publish(tx, events.BetPlaced{
+			UserID:        bet.UserID,
+			GameID:        requirements.CrashGameID,
+			AmountRupee:   bet.Amount,
+			FromCashRupee: bet.FromCashBalance,
+			IsBenefitBet:  bet.IsBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		autoBet.LastBetID = bet.ID
+		autoBet.RoundsPlayed++
+
+		if err := saveCrashGameAutoBet(tx, autoBet); err != nil {
+			return err
+		}
+
+		placedBet = &bet
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if placedBet != nil {
+		logger.Info("Auto-bet placed: ID=%d, UserID=%d, Amount=%.2f, GameID=%d",
+			placedBet.ID, placedBet.UserID, placedBet.Amount, game.ID)
+		CrashGameWS.HandleBet(placedBet.UserID, placedBet)
+	}
+
+	return nil
+}
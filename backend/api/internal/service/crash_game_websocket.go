@@ -202,8 +202,9 @@ func (ws *CrashGameWebsocketService) ForceRestartGame(currentGame *models.CrashG
 	for userId, bet := range ws.bets {
 		if bet.Status == "active" {
 			logger.Info("Принудительно закрываем ставку пользователя %d в зависшей игре", userId)
-			bet.Status = "lost"
-			db.DB.Save(bet)
+			if err := crashGameLose(bet); err != nil {
+				logger.Error("Failed to update lost bet for user %d: %v", userId, err)
+			}
 			// Очищаем список ставок
 			delete(ws.bets, userId)
 		}
@@ -317,10 +318,19 @@ func (ws *CrashGameWebsocketService) SendMultiplierToUser(currentGame *models.Cr
 	for userId, conn := range ws.connections {
 		connections[userId] = conn
 	}
+
+	// Ставки отключенных пользователей (например, автоставки) тоже
+	// должны дойти до автокэшаута и краша
+	activeBets := 0
+	for _, bet := range ws.bets {
+		if bet.Status == "active" {
+			activeBets++
+		}
+	}
 	ws.mu.Unlock()
 
-	if len(connections) == 0 {
-		logger.Info("Нет подключений для игры %d, пропускаем обновления множителя", currentGame.ID)
+	if len(connections) == 0 && activeBets == 0 {
+		logger.Info("Нет подключений и ставок для игры %d, пропускаем обновления множителя", currentGame.ID)
 		return
 	}
 
@@ -426,44 +436,40 @@ multiplierUpdateLoop:
 				// Фиксируем значение для проверки автокэшаута
 				sentMultiplier := currentMultiplier
 
-				// Отправляем всем клиентам
 				ws.mu.Lock()
-				for userId, conn := range connections {
-					// Проверка автокэшаута для активных ставок
-					if bet, exists := ws.bets[userId]; exists && bet.Status == "active" {
-						if (bet.CashOutMultiplier > 0 && sentMultiplier >= bet.CashOutMultiplier && backdoorType != "538") ||
-							(bet.CashOutMultiplier > 0 && sentMultiplier+0.2 >= bet.CashOutMultiplier && backdoorType == "538") {
-							logger.Info("Автоматический кэшаут для пользователя %d на %.2fx", userId, sentMultiplier)
-							if err := crashGameCashout(nil, bet, sentMultiplier); err != nil {
-								logger.Error("Не удалось выполнить автокэшаут для пользователя %d: %v", userId, err)
-								continue
-							}
-
-							ws.ProcessCashout(userId, sentMultiplier, true)
-
+				// Проверка автокэшаута для всех активных ставок,
+				// в том числе пользователей без подключения
+				cashedOut := make(map[int64]bool)
+				for userId, bet := range ws.bets {
+					if bet.Status != "active" {
+						continue
+					}
+					if (bet.CashOutMultiplier > 0 && sentMultiplier >= bet.CashOutMultiplier && backdoorType != "538") ||
+						(bet.CashOutMultiplier > 0 && sentMultiplier+0.2 >= bet.CashOutMultiplier && backdoorType == "538") {
+						logger.Info("Автоматический кэшаут для пользователя %d на %.2fx", userId, sentMultiplier)
+						if err := crashGameCashout(nil, bet, sentMultiplier); err != nil {
+							logger.Error("Не удалось выполнить автокэшаут для пользователя %d: %v", userId, err)
 							continue
 						}
 
-						// Отправляем обновление множителя
-						err := conn.WriteJSON(multiplierInfo)
-						if err != nil {
-							logger.Error("Не удалось отправить обновление множителя пользователю %d: %v", userId, err)
-							if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-								conn.Close()
-								delete(connections, userId)
-								delete(ws.connections, userId)
-							}
-						}
-					} else {
-						// Отправляем обновление наблюдателям
-						err := conn.WriteJSON(multiplierInfo)
-						if err != nil {
-							logger.Error("Не удалось отправить обновление множителя наблюдателю %d: %v", userId, err)
-							if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-								conn.Close()
-								delete(connections, userId)
-								delete(ws.connections, userId)
-							}
+						ws.ProcessCashout(userId, sentMultiplier, true)
+						cashedOut[userId] = true
+					}
+				}
+
+				// Отправляем всем клиентам
+				for userId, conn := range connections {
+					if cashedOut[userId] {
+						continue
+					}
+
+					err := conn.WriteJSON(multiplierInfo)
+					if err != nil {
+						logger.Error("Не удалось отправить обновление множителя пользователю %d: %v", userId, err)
+						if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+							conn.Close()
+							delete(connections, userId)
+							delete(ws.connections, userId)
 						}
 					}
 				}
@@ -564,8 +570,7 @@ multiplierUpdateLoop:
 		for userId, bet := range ws.bets {
 			if bet.Status == "active" {
 				logger.Info("Помечаем ставку как проигранную для пользователя %d", userId)
-				bet.Status = "lost"
-				if err := db.DB.Save(&bet).Error; err != nil {
+				if err := crashGameLose(bet); err != nil {
 					logger.Error("Не удалось обновить проигранную ставку для пользователя %d: %v", userId, err)
 				}
 			}
@@ -600,8 +605,7 @@ func (ws *CrashGameWebsocketService) BroadcastGameCrash(crashPoint float64) {
 
 		// Обновляем статус ставки если она активна
 		if bet, ok := ws.bets[userId]; ok && bet.Status == "active" {
-			bet.Status = "lost"
-			if err := db.DB.Save(&bet).Error; err != nil {
+			if err := crashGameLose(bet); err != nil {
 				logger.Error("Failed to update lost bet for user %d: %v", userId, err)
 			}
 		}
@@ -617,8 +621,9 @@ func (ws *CrashGameWebsocketService) BroadcastGameCrash(crashPoint float64) {
 		for userId, bet := range ws.bets {
 			if bet.Status == "active" {
 				logger.Info("Force resetting active bet for user %d", userId)
-				bet.Status = "lost"
-				db.DB.Save(bet)
+				if err := crashGameLose(bet); err != nil {
+					logger.Error("Failed to update lost bet for user %d: %v", userId, err)
+				}
 			}
 		}
 	}
@@ -665,8 +670,9 @@ func (ws *CrashGameWebsocketService) BroadcastGameStarted() {
 				// Также сбрасываем активные ставки этого пользователя
 				if bet, ok := ws.bets[userId]; ok && bet.Status == "active" {
 					logger.Info("Resetting stale bet for user %d", userId)
-					bet.Status = "lost"
-					db.DB.Save(bet)
+					if err := crashGameLose(bet); err != nil {
+						logger.Error("Failed to update lost bet for user %d: %v", userId, err)
+					}
 					delete(ws.bets, userId)
 				}
 			}
@@ -683,8 +689,9 @@ func (ws *CrashGameWebsocketService) BroadcastGameStarted() {
 
 			// Сбрасываем ставки, если они есть
 			if bet, ok := ws.bets[userId]; ok && bet.Status == "active" {
-				bet.Status = "lost"
-				db.DB.Save(bet)
+				if err := crashGameLose(bet); err != nil {
+					logger.Error("Failed to update lost bet for user %d: %v", userId, err)
+				}
 				delete(ws.bets, userId)
 			}
 		}
@@ -766,8 +773,7 @@ func (ws *CrashGameWebsocketService) SendCrashPointToUser(userId int64, crashPoi
 
 		// Обновляем статус ставки если она активна
 		if bet, ok := ws.bets[userId]; ok && bet.Status == "active" {
-			bet.Status = "lost"
-			if err := db.DB.Save(&bet).Error; err != nil {
+			if err := crashGameLose(bet); err != nil {
 				logger.Error("Failed to update lost bet: %v", err)
 			}
 		}
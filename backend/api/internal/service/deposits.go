@@ -5,8 +5,7 @@ import (
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
 	"BlessedApi/internal/models/benefits/benefit_progress"
-	"BlessedApi/internal/models/requirements/requirement_progress"
-	"BlessedApi/internal/models/travepass"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/pkg/logger"
 	"encoding/json"
 	"strconv"
@@ -119,7 +118,8 @@ func AddDeposit(c *gin.Context, transactionBody TransactionBody) (success bool)
 			return logger.WrapError(err, "")
 		}
 
-		if err = updateReplinishmentTravePassLevelRequirements(tx, user.ID, transactionBody.Amount); err != nil {
+		if err = events.Publish(tx, events.DepositCredited{
+			UserID: user.ID, AmountRupee: transactionBody.Amount}); err != nil {
 			return logger.WrapError(err, "")
 		}
 
@@ -138,27 +138,6 @@ func AddDeposit(c *gin.Context, transactionBody TransactionBody) (success bool)
 	return true
 }
 
-func updateReplinishmentTravePassLevelRequirements(
-	tx *gorm.DB, userID int64, amountRupee float64) error {
-	if tx == nil {
-		tx = db.DB
-	}
-
-	requirementReplenishmentDone, err := requirement_progress.UpdateRequirementProgressReplenishmentIfRequired(
-		tx, userID, amountRupee)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-
-	if requirementReplenishmentDone {
-		if err = travepass.CheckAndUpgradeTravePassLevel(tx, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	}
-
-	return nil
-}
-
 func giveRefereeBonus(tx *gorm.DB, dep *models.Deposit, rupeeAmountWithoutMultiplier float64) error {
 	var otherDepsExists, userIsAReferral bool
 
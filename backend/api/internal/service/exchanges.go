@@ -4,9 +4,8 @@ import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/exchange"
-	"BlessedApi/internal/models/requirements/requirement_progress"
-	"BlessedApi/internal/models/travepass"
 	"BlessedApi/pkg/logger"
 	"errors"
 
@@ -67,8 +66,8 @@ func ExchangeBcoinsToRupee(c *gin.Context) {
 			return logger.WrapError(err, "")
 		}
 
-		if err = updateExchangeTravePassLevelRequirements(
-			tx, userID, input.AmountBcoins); err != nil {
+		if err = events.Publish(tx, events.BcoinsExchanged{
+			UserID: userID, AmountBcoins: input.AmountBcoins}); err != nil {
 			return logger.WrapError(err, "")
 		}
 
@@ -82,27 +81,6 @@ func ExchangeBcoinsToRupee(c *gin.Context) {
 	c.Status(200)
 }
 
-func updateExchangeTravePassLevelRequirements(
-	tx *gorm.DB, userID int64, amountBcoins float64) error {
-	if tx == nil {
-		tx = db.DB
-	}
-
-	requirementExchangeDone, err := requirement_progress.
-		UpdateRequirementProgressExchangeIfRequired(tx, userID, amountBcoins)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-
-	if requirementExchangeDone {
-		if err = travepass.CheckAndUpgradeTravePassLevel(tx, userID); err != nil {
-			return logger.WrapError(err, "")
-		}
-	}
-
-	return nil
-}
-
 func GetUserExchangeBalance(c *gin.Context) {
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
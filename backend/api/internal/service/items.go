@@ -0,0 +1,191 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/items"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RedeemItemInput struct {
+	FullName    string `json:"FullName" validate:"required,max=128"`
+	PhoneNumber string `json:"PhoneNumber" validate:"required,max=32"`
+	Address     string `json:"Address" validate:"required,max=512"`
+}
+
+// itemsError responds with status matching inventory error.
+func itemsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(404, gin.H{"error": "Not found"})
+	case errors.Is(err, items.ErrItemNotOwned):
+		c.JSON(404, gin.H{"error": items.ErrItemNotOwned.Error()})
+	case errors.Is(err, items.ErrItemNotUsable):
+		c.JSON(400, gin.H{"error": items.ErrItemNotUsable.Error()})
+	case errors.Is(err, items.ErrItemNotRedeemable):
+		c.JSON(400, gin.H{"error": items.ErrItemNotRedeemable.Error()})
+	case errors.Is(err, items.ErrFulfilmentFinished):
+		c.JSON(409, gin.H{"error": items.ErrFulfilmentFinished.Error()})
+	default:
+		logger.Error("%v", err)
+		c.Status(500)
+	}
+}
+
+func parseItemID(c *gin.Context) (int64, bool) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid item id"})
+		return 0, false
+	}
+	return itemID, true
+}
+
+// GetUserItems returns user inventory.
+func GetUserItems(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	userItems, err := items.GetUserItems(nil, userID)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if len(userItems) == 0 {
+		c.String(404, "[]")
+		return
+	}
+
+	c.JSON(200, userItems)
+}
+
+// UseUserItem uses item from user inventory. Booster is consumed
+// and its benefit is applied, cosmetic is equipped.
+func UseUserItem(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	itemID, ok := parseItemID(c)
+	if !ok {
+		return
+	}
+
+	var userItem *items.UserItem
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		userItem, err = items.UseUserItem(tx, userID, itemID)
+		if err != nil {
+			return err
+		}
+
+		if userItem.Item.Kind != items.ItemKindBooster {
+			return nil
+		}
+
+		var benefit benefits.Benefit
+		if err = tx.First(&benefit, userItem.Item.BenefitID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+		if err = benefit.PreloadPolymorphicBenefit(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		err = benefit_progress.CreateOrApplyPolymorphicBenefitProgress(tx, &benefit, userID)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		itemsError(c, err)
+		return
+	}
+
+	c.JSON(200, userItem)
+}
+
+// RedeemUserItem takes physical item from user inventory
+// and creates delivery request.
+func RedeemUserItem(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	itemID, ok := parseItemID(c)
+	if !ok {
+		return
+	}
+
+	var input RedeemItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fulfilment := items.ItemFulfilment{
+		FullName:    input.FullName,
+		PhoneNumber: input.PhoneNumber,
+		Address:     input.Address,
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		return items.RedeemUserItem(tx, userID, itemID, &fulfilment)
+	})
+	if err != nil {
+		itemsError(c, err)
+		return
+	}
+
+	c.JSON(200, fulfilment)
+}
+
+// GetUserItemFulfilments returns user delivery requests, newest first.
+func GetUserItemFulfilments(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var fulfilments []items.ItemFulfilment
+	err = db.DB.Preload("Item").Where("user_id = ?", userID).
+		Order("id desc").Find(&fulfilments).Error
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if len(fulfilments) == 0 {
+		c.String(404, "[]")
+		return
+	}
+
+	c.JSON(200, fulfilments)
+}
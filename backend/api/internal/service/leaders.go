@@ -0,0 +1,35 @@
+package service
+
+import (
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	events.Subscribe(recordLeadersWinning)
+}
+
+// recordLeadersWinning records won bet payout for GetLeaders.
+// Binary options are not counted in leaders.
+func recordLeadersWinning(tx *gorm.DB, event events.Event) error {
+	bet, ok := event.(events.BetSettled)
+	if !ok || !bet.Won || bet.PayoutRupee == 0 ||
+		bet.GameID == requirements.BinaryOptionGameID {
+		return nil
+	}
+
+	win := models.Winning{
+		UserID:    bet.UserID,
+		WinAmount: bet.PayoutRupee,
+	}
+
+	if err := tx.Create(&win).Error; err != nil {
+		return logger.WrapError(err, "Failed to record winning")
+	}
+
+	return nil
+}
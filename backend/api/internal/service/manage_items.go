@@ -0,0 +1,206 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/items"
+	"BlessedApi/pkg/logger"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ManageItemInput defines catalogue item. Benefit is applied when
+// booster is used, Premium of the benefit input is ignored.
+type ManageItemInput struct {
+	Name         string                           `json:"Name" validate:"required"`
+	Description  string                           `json:"Description"`
+	ImageURL     string                           `json:"ImageURL" validate:"omitempty,url"`
+	Kind         items.ItemKind                   `json:"Kind" validate:"required,oneof=booster cosmetic physical"`
+	CosmeticSlot items.CosmeticSlot               `json:"CosmeticSlot" validate:"required_if=Kind cosmetic,omitempty,oneof=avatar frame"`
+	Benefit      *ManageTravePassPolymorphicInput `json:"Benefit" validate:"required_if=Kind booster"`
+}
+
+type ManageItemFulfilmentInput struct {
+	Status  items.ItemFulfilmentStatus `json:"Status" validate:"required,oneof=shipped delivered rejected"`
+	Comment string                     `json:"Comment"`
+}
+
+var errInvalidBenefitItem = errors.New("item doesn't exist or is disabled")
+
+// checkBenefitItem returns errInvalidBenefitItem if polymorphic
+// benefit gives missing or disabled item.
+func checkBenefitItem(tx *gorm.DB, polymorphicBenefit interface{}) error {
+	benefitItem, ok := polymorphicBenefit.(*benefits.BenefitItem)
+	if !ok {
+		return nil
+	}
+
+	item, err := items.GetItem(tx, benefitItem.ItemID)
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return errInvalidBenefitItem
+	} else if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if item.Disabled {
+		return errInvalidBenefitItem
+	}
+
+	return nil
+}
+
+func ManageGetItems(c *gin.Context) {
+	var catalogue []items.Item
+	if err := db.DB.Order("id").Find(&catalogue).Error; err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, catalogue)
+}
+
+// ManageCreateItem creates catalogue item, booster benefit is created
+// with it. Booster can't give items.
+func ManageCreateItem(c *gin.Context) {
+	var input ManageItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := items.Item{
+		Name:        input.Name,
+		Description: input.Description,
+		ImageURL:    input.ImageURL,
+		Kind:        input.Kind,
+	}
+	if input.Kind == items.ItemKindCosmetic {
+		item.CosmeticSlot = input.CosmeticSlot
+	}
+
+	var polymorphicBenefit interface{}
+	if input.Kind == items.ItemKindBooster {
+		if input.Benefit.Type == benefits.BenefitItemType {
+			c.JSON(400, gin.H{"error": "booster can't give items"})
+			return
+		}
+
+		var err error
+		polymorphicBenefit, err = benefits.NewPolymorphicBenefit(input.Benefit.Type)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err = decodePolymorphicParams(polymorphicBenefit, input.Benefit.Params, 0); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if polymorphicBenefit != nil {
+			if err := tx.Create(polymorphicBenefit).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			benefit := benefits.Benefit{
+				PolymorphicBenefitID:   polymorphicModelID(polymorphicBenefit),
+				PolymorphicBenefitType: input.Benefit.Type,
+			}
+			if err := tx.Create(&benefit).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			item.BenefitID = benefit.ID
+		}
+
+		if err := tx.Create(&item).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, item)
+}
+
+// ManageDisableItem stops giving item by benefits, item is kept
+// in user inventories.
+func ManageDisableItem(c *gin.Context) {
+	itemID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	if err := items.DisableItem(nil, itemID); err != nil {
+		itemsError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
+
+// ManageGetItemFulfilments returns delivery requests, filtered by
+// status query parameter if given.
+func ManageGetItemFulfilments(c *gin.Context) {
+	query := db.DB.Preload("Item").Order("id desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var fulfilments []items.ItemFulfilment
+	if err := query.Find(&fulfilments).Error; err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, fulfilments)
+}
+
+// ManageUpdateItemFulfilment changes status of delivery request,
+// rejected request returns the item to user inventory.
+func ManageUpdateItemFulfilment(c *gin.Context) {
+	fulfilmentID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageItemFulfilmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var fulfilment *items.ItemFulfilment
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		fulfilment, err = items.UpdateItemFulfilmentStatus(tx, fulfilmentID, input.Status, input.Comment)
+		return err
+	})
+	if err != nil {
+		itemsError(c, err)
+		return
+	}
+
+	c.JSON(200, fulfilment)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/quests"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ManageQuestInput defines quest with its requirement and benefits,
+// Premium of polymorphic inputs is ignored.
+type ManageQuestInput struct {
+	Name        string                            `json:"Name" validate:"required"`
+	Description string                            `json:"Description"`
+	Period      quests.QuestPeriod                `json:"Period" validate:"required,oneof=daily weekly"`
+	StartsAt    *time.Time                        `json:"StartsAt"`
+	EndsAt      *time.Time                        `json:"EndsAt"`
+	Requirement ManageTravePassPolymorphicInput   `json:"Requirement" validate:"required"`
+	Benefits    []ManageTravePassPolymorphicInput `json:"Benefits" validate:"required,min=1,dive"`
+}
+
+// ManageGetQuests returns all quests, including disabled ones.
+func ManageGetQuests(c *gin.Context) {
+	var allQuests []quests.Quest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Preload("Requirement").Preload("Benefits.Benefit").
+			Order("id").Find(&allQuests).Error
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		for i := range allQuests {
+			if err = allQuests[i].PreloadPolymorphics(tx); err != nil {
+				return logger.WrapError(err, "")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, allQuests)
+}
+
+// ManageCreateQuest creates quest with polymorphic requirement and
+// benefits. Quest is given to users from the next period.
+func ManageCreateQuest(c *gin.Context) {
+	var input ManageQuestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		c.JSON(400, gin.H{"error": "EndsAt must be after StartsAt"})
+		return
+	}
+
+	polymorphicRequirement, err := requirements.NewPolymorphicRequirement(input.Requirement.Type)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = decodePolymorphicParams(polymorphicRequirement, input.Requirement.Params, 0); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	polymorphicBenefits := make([]interface{}, len(input.Benefits))
+	for i, benefitInput := range input.Benefits {
+		polymorphicBenefits[i], err = benefits.NewPolymorphicBenefit(benefitInput.Type)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err = decodePolymorphicParams(polymorphicBenefits[i], benefitInput.Params, 0); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = checkBenefitItem(nil, polymorphicBenefits[i])
+		if err != nil && errors.Is(err, errInvalidBenefitItem) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Error("%v", err)
+			c.Status(500)
+			return
+		}
+	}
+
+	quest := quests.Quest{
+		Name:        input.Name,
+		Description: input.Description,
+		Period:      input.Period,
+		StartsAt:    input.StartsAt,
+		EndsAt:      input.EndsAt,
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(polymorphicRequirement).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		quest.Requirement = requirements.Requirement{
+			PolymorphicRequirementID:   polymorphicModelID(polymorphicRequirement),
+			PolymorphicRequirementType: input.Requirement.Type,
+		}
+		if err := tx.Create(&quest.Requirement).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+		quest.Requirement.PolymorphicRequirement = reflect.ValueOf(polymorphicRequirement).Elem().Interface()
+		quest.RequirementID = quest.Requirement.ID
+
+		questBenefits := make([]benefits.Benefit, len(polymorphicBenefits))
+		for i, polymorphicBenefit := range polymorphicBenefits {
+			if err := tx.Create(polymorphicBenefit).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			questBenefits[i] = benefits.Benefit{
+				PolymorphicBenefitID:   polymorphicModelID(polymorphicBenefit),
+				PolymorphicBenefitType: input.Benefits[i].Type,
+			}
+			if err := tx.Create(&questBenefits[i]).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+			questBenefits[i].PolymorphicBenefit = reflect.ValueOf(polymorphicBenefit).Elem().Interface()
+		}
+
+		return quests.CreateQuest(tx, &quest, questBenefits)
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, quest)
+}
+
+// ManageDisableQuest takes quest out of rotation from the next
+// period, quest is kept for users who already got it.
+func ManageDisableQuest(c *gin.Context) {
+	questID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	if err := quests.DisableQuest(nil, questID); err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
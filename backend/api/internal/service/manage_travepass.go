@@ -0,0 +1,522 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models/benefits"
+	"BlessedApi/internal/models/requirements"
+	"BlessedApi/internal/models/travepass"
+	"BlessedApi/pkg/logger"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ManageTravePassSeasonInput struct {
+	Name              string    `json:"Name" validate:"required"`
+	StartsAt          time.Time `json:"StartsAt" validate:"required"`
+	EndsAt            time.Time `json:"EndsAt" validate:"required,gtfield=StartsAt"`
+	PremiumPriceRupee float64   `json:"PremiumPriceRupee" validate:"min=0"`
+	LevelsCount       int64     `json:"LevelsCount" validate:"min=0,max=1000"`
+}
+
+type ManageTravePassLevelsOrderInput struct {
+	LevelIDs []int64 `json:"LevelIDs" validate:"required"`
+}
+
+// ManageTravePassPolymorphicInput is polymorphic requirement or benefit,
+// Params are fields of the polymorphic model of given Type. Premium
+// puts benefit on the premium track, it is ignored for requirements.
+type ManageTravePassPolymorphicInput struct {
+	Type    string          `json:"Type" validate:"required"`
+	Params  json.RawMessage `json:"Params" validate:"required"`
+	Premium bool            `json:"Premium"`
+}
+
+type ManageTravePassParamsInput struct {
+	Params json.RawMessage `json:"Params" validate:"required"`
+}
+
+// manageTravePassError responds with status matching
+// trave pass management error.
+func manageTravePassError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(404, gin.H{"error": "Not found"})
+	case errors.Is(err, travepass.ErrStartLevel):
+		c.JSON(409, gin.H{"error": travepass.ErrStartLevel.Error()})
+	case errors.Is(err, travepass.ErrLevelReached):
+		c.JSON(409, gin.H{"error": travepass.ErrLevelReached.Error()})
+	case errors.Is(err, travepass.ErrInvalidLevelsOrder):
+		c.JSON(400, gin.H{"error": travepass.ErrInvalidLevelsOrder.Error()})
+	default:
+		logger.Error("%v", err)
+		c.Status(500)
+	}
+}
+
+func parseManageID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// decodePolymorphicParams fills polymorphic model with params and
+// validates it. Model id is set to given one, params can't change it.
+func decodePolymorphicParams(model interface{}, params json.RawMessage, id int64) error {
+	if err := json.Unmarshal(params, model); err != nil {
+		return errors.New("invalid params")
+	}
+
+	reflect.ValueOf(model).Elem().FieldByName("ID").SetInt(id)
+
+	return validate.Struct(model)
+}
+
+func polymorphicModelID(model interface{}) int64 {
+	return reflect.ValueOf(model).Elem().FieldByName("ID").Int()
+}
+
+func ManageGetTravePassSeasons(c *gin.Context) {
+	var seasons []travepass.TravePassSeason
+	if err := db.DB.Order("starts_at desc").Find(&seasons).Error; err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, seasons)
+}
+
+// ManageCreateTravePassSeason creates season with LevelsCount empty levels.
+func ManageCreateTravePassSeason(c *gin.Context) {
+	var input ManageTravePassSeasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	season := travepass.TravePassSeason{
+		Name:              input.Name,
+		StartsAt:          input.StartsAt,
+		EndsAt:            input.EndsAt,
+		PremiumPriceRupee: input.PremiumPriceRupee,
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return travepass.CreateTravePassSeason(tx, &season, input.LevelsCount)
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, season)
+}
+
+// ManageEditTravePassSeason changes season name, dates and premium
+// price, LevelsCount is ignored.
+func ManageEditTravePassSeason(c *gin.Context) {
+	seasonID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassSeasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var season travepass.TravePassSeason
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&season, seasonID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		season.Name = input.Name
+		season.StartsAt = input.StartsAt
+		season.EndsAt = input.EndsAt
+		season.PremiumPriceRupee = input.PremiumPriceRupee
+
+		if err := tx.Save(&season).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, season)
+}
+
+func ManageGetTravePassSeasonLevels(c *gin.Context) {
+	seasonID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	levels, err := travepass.GetAllTravePassLevelsWithRequirementsAndBenefits(nil, seasonID)
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, *levels)
+}
+
+func ManageAddTravePassLevel(c *gin.Context) {
+	seasonID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var level *travepass.TravePassLevel
+	err := db.DB.Transaction(func(tx *gorm.DB) (err error) {
+		level, err = travepass.AddTravePassLevel(tx, seasonID)
+		return err
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, level)
+}
+
+// ManageReorderTravePassLevels sets order of season levels after
+// the starting one. Levels already reached by users can't move.
+func ManageReorderTravePassLevels(c *gin.Context) {
+	seasonID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassLevelsOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return travepass.ReorderTravePassLevels(tx, seasonID, input.LevelIDs)
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
+
+func ManageDeleteTravePassLevel(c *gin.Context) {
+	levelID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return travepass.DeleteTravePassLevel(tx, levelID)
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
+
+// ManageAddTravePassLevelRequirement creates polymorphic requirement
+// and attaches it to the level.
+func ManageAddTravePassLevelRequirement(c *gin.Context) {
+	levelID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassPolymorphicInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	polymorphicRequirement, err := requirements.NewPolymorphicRequirement(input.Type)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = decodePolymorphicParams(polymorphicRequirement, input.Params, 0); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var levelRequirement *travepass.TravePassLevelRequirement
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(polymorphicRequirement).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		requirement := requirements.Requirement{
+			PolymorphicRequirementID:   polymorphicModelID(polymorphicRequirement),
+			PolymorphicRequirementType: input.Type,
+		}
+		if err := tx.Create(&requirement).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+		requirement.PolymorphicRequirement = reflect.ValueOf(polymorphicRequirement).Elem().Interface()
+
+		levelRequirement, err = travepass.AddTravePassLevelRequirement(tx, levelID, &requirement)
+		return err
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, levelRequirement)
+}
+
+// ManageEditTravePassRequirement changes polymorphic requirement params
+// of the level. Users progress of the requirement is checked against new
+// params right away and reset if it counted other events.
+func ManageEditTravePassRequirement(c *gin.Context) {
+	requirementID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassParamsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requirement requirements.Requirement
+	errInvalidParams := errors.New("invalid params")
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&requirement, requirementID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if err := requirement.PreloadPolymorphicRequirement(tx); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		polymorphicRequirement, err := requirements.NewPolymorphicRequirement(requirement.PolymorphicRequirementType)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if err = decodePolymorphicParams(polymorphicRequirement,
+			input.Params, requirement.PolymorphicRequirementID); err != nil {
+			return errors.Join(errInvalidParams, err)
+		}
+
+		return travepass.EditTravePassLevelRequirement(tx, &requirement, polymorphicRequirement)
+	})
+	if err != nil && errors.Is(err, errInvalidParams) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, requirement)
+}
+
+func ManageDeleteTravePassRequirement(c *gin.Context) {
+	requirementID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return travepass.DeleteTravePassLevelRequirement(tx, requirementID)
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
+
+// ManageAddTravePassLevelBenefit creates polymorphic benefit
+// and attaches it to the level.
+func ManageAddTravePassLevelBenefit(c *gin.Context) {
+	levelID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassPolymorphicInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	polymorphicBenefit, err := benefits.NewPolymorphicBenefit(input.Type)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = decodePolymorphicParams(polymorphicBenefit, input.Params, 0); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = checkBenefitItem(nil, polymorphicBenefit); err != nil && errors.Is(err, errInvalidBenefitItem) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var levelBenefit *travepass.TravePassLevelBenefit
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(polymorphicBenefit).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		benefit := benefits.Benefit{
+			PolymorphicBenefitID:   polymorphicModelID(polymorphicBenefit),
+			PolymorphicBenefitType: input.Type,
+		}
+		if err := tx.Create(&benefit).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+		benefit.PolymorphicBenefit = reflect.ValueOf(polymorphicBenefit).Elem().Interface()
+
+		levelBenefit, err = travepass.AddTravePassLevelBenefit(tx, levelID, &benefit, input.Premium)
+		return err
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, levelBenefit)
+}
+
+// ManageEditTravePassBenefit changes polymorphic benefit params,
+// benefits already given to users are not changed.
+func ManageEditTravePassBenefit(c *gin.Context) {
+	benefitID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	var input ManageTravePassParamsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var benefit benefits.Benefit
+	errInvalidParams := errors.New("invalid params")
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&benefit, benefitID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		polymorphicBenefit, err := benefits.NewPolymorphicBenefit(benefit.PolymorphicBenefitType)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		if err = decodePolymorphicParams(polymorphicBenefit,
+			input.Params, benefit.PolymorphicBenefitID); err != nil {
+			return errors.Join(errInvalidParams, err)
+		}
+
+		if err = checkBenefitItem(tx, polymorphicBenefit); err != nil && errors.Is(err, errInvalidBenefitItem) {
+			return errors.Join(errInvalidParams, err)
+		} else if err != nil {
+			return err
+		}
+
+		if err = tx.Save(polymorphicBenefit).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return benefit.PreloadPolymorphicBenefit(tx)
+	})
+	if err != nil && errors.Is(err, errInvalidParams) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.JSON(200, benefit)
+}
+
+func ManageDeleteTravePassBenefit(c *gin.Context) {
+	benefitID, ok := parseManageID(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return travepass.DeleteTravePassLevelBenefit(tx, benefitID)
+	})
+	if err != nil {
+		manageTravePassError(c, err)
+		return
+	}
+
+	c.Status(200)
+}
@@ -25,7 +25,8 @@ func GetUserFreeMiniGameBets(c *gin.Context) {
 
 	if gameID != requirements.NvutiGameID &&
 		gameID != requirements.DiceGameID &&
-		gameID != requirements.RouletteGameID {
+		gameID != requirements.RouletteGameID &&
+		gameID != requirements.CrashGameID {
 		c.JSON(400, gin.H{"error": "no game with this id"})
 		return
 	}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models/quests"
+	"BlessedApi/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetUserQuests returns user quests of current daily and weekly periods
+// with requirements, benefits and progress of uncompleted quests.
+// Quests of new periods are given on request if not given yet.
+func GetUserQuests(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var userQuests []quests.UserQuest
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := quests.EnsureUserQuests(tx, userID); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		userQuests, err = quests.GetCurrentUserQuests(tx, userID)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if len(userQuests) == 0 {
+		c.String(404, "[]")
+		return
+	}
+
+	c.JSON(200, userQuests)
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models"
+	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/events"
+	"BlessedApi/internal/models/exchange"
+	"BlessedApi/pkg/logger"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const HouseEdge = 0.05
+
+type RangeRollMode string
+
+const (
+	RangeRollOver    RangeRollMode = "over"
+	RangeRollUnder   RangeRollMode = "under"
+	RangeRollInside  RangeRollMode = "inside"
+	RangeRollOutside RangeRollMode = "outside"
+)
+
+// RangeRollDirection is a direction of legacy bet input
+// with integer win percent.
+type RangeRollDirection string
+
+const (
+	RangeRollLess RangeRollDirection = "less"
+	RangeRollMore RangeRollDirection = "more"
+)
+
+// RangeRollVariant configures range-roll game. Roll is a uniform
+// number from 0 to 100 (exclusive) with Precision decimals, at most 4,
+// targets use the same precision. MaxBet equal to zero means no limit.
+type RangeRollVariant struct {
+	GameID    int64
+	Precision int
+	MinTarget float64
+	MaxTarget float64
+	// Win chance limits in percent
+	MinChance float64
+	MaxChance float64
+	MinBet    float64
+	MaxBet    float64
+	HouseEdge float64
+	Modes     []RangeRollMode
+}
+
+// RangeRollBetInput defines range-roll bet. Target is used by
+// over/under modes, RangeFrom and RangeTo by inside/outside modes.
+// If Mode is empty, legacy WinPercent and Direction are used.
+type RangeRollBetInput struct {
+	Amount    float64       `json:"amount" validate:"required,gt=0"`
+	Mode      RangeRollMode `json:"mode" validate:"omitempty,oneof=over under inside outside"`
+	Target    float64       `json:"target" validate:"min=0,max=100"`
+	RangeFrom float64       `json:"rangeFrom" validate:"min=0,max=100"`
+	RangeTo   float64       `json:"rangeTo" validate:"min=0,max=100"`
+
+	WinPercent int64              `json:"winPercent" validate:"required_without=Mode,omitempty,min=1,max=99"`
+	Direction  RangeRollDirection `json:"direction" validate:"required_without=Mode,omitempty,oneof=more less"`
+}
+
+type RangeRollBetResult struct {
+	Won bool `json:"won"`
+	// Rolled number scaled to 0..999999 like in legacy results,
+	// Roll is the same number in percent with variant precision
+	Number     int     `json:"number"`
+	Roll       float64 `json:"roll"`
+	Chance     float64 `json:"chance"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+var errRangeRollInvalidBet = errors.New("invalid bet")
+
+// rangeRollBet is a validated bet in ticks, win range is [from, to].
+// Outside mode wins when roll is out of the range.
+type rangeRollBet struct {
+	from, to int
+	outside  bool
+}
+
+// ticks returns number of possible roll outcomes.
+func (v *RangeRollVariant) ticks() int {
+	return 100 * int(math.Pow10(v.Precision))
+}
+
+func (v *RangeRollVariant) toTicks(value float64) int {
+	return int(math.Round(value * math.Pow10(v.Precision)))
+}
+
+func (v *RangeRollVariant) fromTicks(ticks int) float64 {
+	return float64(ticks) / math.Pow10(v.Precision)
+}
+
+// legacyNumber scales ticks to legacy roll number from 0 to 999999.
+// Precision of variant is at most 4 decimals.
+func (v *RangeRollVariant) legacyNumber(ticks int) int {
+	return ticks * int(math.Pow10(4-v.Precision))
+}
+
+func (v *RangeRollVariant) supportsMode(mode RangeRollMode) bool {
+	for _, m := range v.Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *RangeRollVariant) checkTarget(target float64) error {
+	if target < v.MinTarget || target > v.MaxTarget {
+		return fmt.Errorf("%w: target must be between %v and %v",
+			errRangeRollInvalidBet, v.MinTarget, v.MaxTarget)
+	}
+	if math.Abs(v.fromTicks(v.toTicks(target))-target) > 1e-9 {
+		return fmt.Errorf("%w: target precision is %d decimals",
+			errRangeRollInvalidBet, v.Precision)
+	}
+	return nil
+}
+
+// newBet validates input against variant limits and converts it to ticks.
+func (v *RangeRollVariant) newBet(input RangeRollBetInput) (rangeRollBet, error) {
+	if input.Amount < v.MinBet {
+		return rangeRollBet{}, fmt.Errorf("%w: amount must be at least %v",
+			errRangeRollInvalidBet, v.MinBet)
+	}
+	if v.MaxBet > 0 && input.Amount > v.MaxBet {
+		return rangeRollBet{}, fmt.Errorf("%w: amount must be at most %v",
+			errRangeRollInvalidBet, v.MaxBet)
+	}
+
+	var bet rangeRollBet
+	last := v.ticks() - 1
+
+	switch input.Mode {
+	case "":
+		// Legacy bet wins in WinPercent of outcomes
+		winTicks := int(input.WinPercent) * v.ticks() / 100
+		if input.Direction == RangeRollLess {
+			bet = rangeRollBet{from: 0, to: winTicks - 1}
+		} else {
+			bet = rangeRollBet{from: last - winTicks + 1, to: last}
+		}
+	case RangeRollOver, RangeRollUnder:
+		if !v.supportsMode(input.Mode) {
+			return rangeRollBet{}, fmt.Errorf("%w: mode %s is not supported",
+				errRangeRollInvalidBet, input.Mode)
+		}
+		if err := v.checkTarget(input.Target); err != nil {
+			return rangeRollBet{}, err
+		}
+		target := v.toTicks(input.Target)
+		if input.Mode == RangeRollOver {
+			bet = rangeRollBet{from: target + 1, to: last}
+		} else {
+			bet = rangeRollBet{from: 0, to: target - 1}
+		}
+	case RangeRollInside, RangeRollOutside:
+		if !v.supportsMode(input.Mode) {
+			return rangeRollBet{}, fmt.Errorf("%w: mode %s is not supported",
+				errRangeRollInvalidBet, input.Mode)
+		}
+		if err := v.checkTarget(input.RangeFrom); err != nil {
+			return rangeRollBet{}, err
+		}
+		if err := v.checkTarget(input.RangeTo); err != nil {
+			return rangeRollBet{}, err
+		}
+		if input.RangeFrom >= input.RangeTo {
+			return rangeRollBet{}, fmt.Errorf("%w: rangeFrom must be less than rangeTo",
+				errRangeRollInvalidBet)
+		}
+		bet = rangeRollBet{
+			from:    v.toTicks(input.RangeFrom),
+			to:      v.toTicks(input.RangeTo),
+			outside: input.Mode == RangeRollOutside,
+		}
+	}
+
+	chance := v.chance(bet)
+	if chance < v.MinChance || chance > v.MaxChance {
+		return rangeRollBet{}, fmt.Errorf("%w: win chance must be between %v%% and %v%%",
+			errRangeRollInvalidBet, v.MinChance, v.MaxChance)
+	}
+
+	return bet, nil
+}
+
+// chance returns win chance of the bet in percent.
+func (v *RangeRollVariant) chance(bet rangeRollBet) float64 {
+	winTicks := bet.to - bet.from + 1
+	if bet.outside {
+		winTicks = v.ticks() - winTicks
+	}
+	return float64(winTicks) / float64(v.ticks()) * 100
+}
+
+func (v *RangeRollVariant) winMultiplier(bet rangeRollBet) float64 {
+	return 100 / v.chance(bet) * (1 - v.HouseEdge)
+}
+
+func (v *RangeRollVariant) roll(bet rangeRollBet) RangeRollBetResult {
+	number := rand.Intn(v.ticks())
+
+	won := number >= bet.from && number <= bet.to
+	if bet.outside {
+		won = !won
+	}
+
+	return RangeRollBetResult{
+		Won:        won,
+		Number:     v.legacyNumber(number),
+		Roll:       v.fromTicks(number),
+		Chance:     v.chance(bet),
+		Multiplier: v.winMultiplier(bet),
+	}
+}
+
+// rangeRollPlaceBet returns handler placing bets of given variant.
+func rangeRollPlaceBet(variant *RangeRollVariant) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := middleware.GetUserIDFromGinContext(c)
+		if err != nil {
+			logger.Error("%v", err)
+			c.Status(500)
+			return
+		}
+
+		var input RangeRollBetInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Status(400)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		bet, err := variant.newBet(input)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, payout, err := placeRangeRollBet(variant, userID, input.Amount, bet)
+		if err != nil && errors.Is(err, ErrInsufficientBalance) {
+			c.JSON(402, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			logger.Error("%v", err)
+			c.Status(500)
+			return
+		}
+
+		c.JSON(200, gin.H{
+			"result": result,
+			"payout": payout,
+		})
+	}
+}
+
+// placeRangeRollBet charges user, rolls and pays out the winnings.
+// Free mini-game bet of the variant game is used if available,
+// in that case amount is ignored. Returns result and payout.
+func placeRangeRollBet(variant *RangeRollVariant, userID int64, amount float64,
+	bet rangeRollBet) (RangeRollBetResult, float64, error) {
+	var result RangeRollBetResult
+	var toCashBalance, toBonusBalance float64
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		// Get user free bets
+		benefitFreeDeposit, applyBenefit, err :=
+			benefit_progress.UseFreeMiniGameBetIfAvailable(tx, userID, variant.GameID)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		var fromCashBalance, fromBonusBalance float64
+		isBenefitBet := benefitFreeDeposit != 0
+
+		if !isBenefitBet {
+			bonusBalance, err := exchange.GetUserExchangedBalanceAmount(tx, user.ID)
+			if err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			// User dont have enough money on both balances
+			if user.BalanceRupee+bonusBalance < amount {
+				return ErrInsufficientBalance
+			}
+
+			// Pay with mixed balances
+			fromCashBalance, fromBonusBalance, err = exchange.UseExchangeBalancePayment(tx, &user, amount)
+			if err != nil {
+				return logger.WrapError(err, "")
+			}
+		} else {
+			if err = applyBenefit(tx); err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			// Free bet is paid out to bonus balance only
+			fromBonusBalance = benefitFreeDeposit
+		}
+
+		err = events.Publish(tx, events.BetPlaced{
+			UserID:        user.ID,
+			GameID:        variant.GameID,
+			AmountRupee:   fromCashBalance + fromBonusBalance,
+			FromCashRupee: fromCashBalance,
+			IsBenefitBet:  isBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		result = variant.roll(bet)
+
+		if result.Won {
+			toCashBalance = fromCashBalance * result.Multiplier
+			toBonusBalance = fromBonusBalance * result.Multiplier
+		}
+
+		// Update both balances even if won is false
+		err = exchange.UpdateUserBalances(tx, &user, toCashBalance, toBonusBalance,
+			result.Won && isBenefitBet)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		err = events.Publish(tx, events.BetSettled{
+			UserID:          user.ID,
+			GameID:          variant.GameID,
+			AmountRupee:     fromCashBalance + fromBonusBalance,
+			FromCashRupee:   fromCashBalance,
+			Won:             result.Won,
+			PayoutRupee:     toCashBalance + toBonusBalance,
+			CashPayoutRupee: toCashBalance,
+			IsBenefitBet:    isBenefitBet,
+		})
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return RangeRollBetResult{}, 0, err
+	}
+
+	return result, toCashBalance + toBonusBalance, nil
+}
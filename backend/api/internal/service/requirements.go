@@ -3,6 +3,7 @@ package service
 import (
 	"BlessedApi/cmd/db"
 	"BlessedApi/internal/middleware"
+	"BlessedApi/internal/models/quests"
 	"BlessedApi/internal/models/requirements/requirement_progress"
 	"BlessedApi/pkg/logger"
 	"errors"
@@ -23,12 +24,15 @@ func GetUserRequirementsProgress(c *gin.Context) {
 	errNotFound := errors.New("requirement progresses not found")
 
 	err = db.DB.Transaction(func(tx *gorm.DB) error {
-		err = db.DB.Preload("Requirement").Find(&reqProgs, "user_id = ?", userID).Error
+		// Quest progresses are returned with user quests
+		questRequirementIDs := db.DB.Model(&quests.Quest{}).Select("requirement_id")
+		err = db.DB.Preload("Requirement").Find(&reqProgs,
+			"user_id = ? AND requirement_id NOT IN (?)", userID, questRequirementIDs).Error
 		if err != nil {
 			return logger.WrapError(err, "")
 		}
 
-		// Should happen only when user on 60lvl
+		// Should happen only when user on the last season level
 		if len(reqProgs) == 0 {
 			return errNotFound
 		}
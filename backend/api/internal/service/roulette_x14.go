@@ -5,10 +5,9 @@ import (
 	"BlessedApi/internal/middleware"
 	"BlessedApi/internal/models"
 	"BlessedApi/internal/models/benefits/benefit_progress"
+	"BlessedApi/internal/models/events"
 	"BlessedApi/internal/models/exchange"
 	"BlessedApi/internal/models/requirements"
-	"BlessedApi/internal/models/requirements/requirement_progress"
-	"BlessedApi/internal/models/travepass"
 	"BlessedApi/pkg/logger"
 	"errors"
 	"math/rand"
@@ -23,45 +22,140 @@ var ErrInsufficientBalance = errors.New("insufficient balance")
 
 // RouletteX14Sector defines the sector properties in the Roulette X14 game.
 type RouletteX14Sector struct {
-	Color        string `json:"color"`
-	SectorId     int    `json:"sector_id"`
-	SectorNumber int    `json:"sector_number"`
+	Color        string  `json:"color"`
+	SectorId     int     `json:"sector_id"`
+	SectorNumber int     `json:"sector_number"`
+	NumberPayout float64 `json:"number_payout"`
 }
 
-// RouletteX14BetInput defines the structure of a bet input.
-type RouletteX14BetInput struct {
+// RouletteX14BetEntryInput defines a single entry of the bet slip.
+type RouletteX14BetEntryInput struct {
+	Type   string  `json:"type" binding:"required,oneof=color number"`
+	Color  string  `json:"color" binding:"required_if=Type color,omitempty,oneof=black red green"`
+	Number *int    `json:"number" binding:"required_if=Type number,omitempty,min=0,max=14"`
 	Amount float64 `json:"amount" binding:"required,gt=0"`
-	Color  string  `json:"color" binding:"required,oneof=black red green"`
+}
+
+// RouletteX14BetInput defines the structure of a bet slip input.
+// Single color bet with Amount and Color is still accepted.
+type RouletteX14BetInput struct {
+	Entries []RouletteX14BetEntryInput `json:"entries" binding:"omitempty,max=18,dive"`
+	Amount  float64                    `json:"amount" binding:"omitempty,gt=0"`
+	Color   string                     `json:"color" binding:"omitempty,oneof=black red green"`
+}
+
+// RouletteX14ColorPayouts defines payout multipliers of color bets.
+var RouletteX14ColorPayouts = map[string]float64{
+	"red":   2,
+	"black": 2,
+	"green": 14,
 }
 
 // Predefined sectors on the Roulette X14 wheel.
 var RouletteX14Sectors = []RouletteX14Sector{
-	{Color: "red", SectorId: 1, SectorNumber: 1},
-	{Color: "black", SectorId: 2, SectorNumber: 8},
-	{Color: "red", SectorId: 3, SectorNumber: 2},
-	{Color: "black", SectorId: 4, SectorNumber: 9},
-	{Color: "red", SectorId: 5, SectorNumber: 3},
-	{Color: "black", SectorId: 6, SectorNumber: 10},
-	{Color: "red", SectorId: 7, SectorNumber: 4},
-	{Color: "black", SectorId: 8, SectorNumber: 11},
-	{Color: "red", SectorId: 9, SectorNumber: 5},
-	{Color: "black", SectorId: 10, SectorNumber: 12},
-	{Color: "red", SectorId: 11, SectorNumber: 6},
-	{Color: "black", SectorId: 12, SectorNumber: 13},
-	{Color: "red", SectorId: 13, SectorNumber: 7},
-	{Color: "black", SectorId: 14, SectorNumber: 14},
-	{Color: "green", SectorId: 15, SectorNumber: 0},
+	{Color: "red", SectorId: 1, SectorNumber: 1, NumberPayout: 14},
+	{Color: "black", SectorId: 2, SectorNumber: 8, NumberPayout: 14},
+	{Color: "red", SectorId: 3, SectorNumber: 2, NumberPayout: 14},
+	{Color: "black", SectorId: 4, SectorNumber: 9, NumberPayout: 14},
+	{Color: "red", SectorId: 5, SectorNumber: 3, NumberPayout: 14},
+	{Color: "black", SectorId: 6, SectorNumber: 10, NumberPayout: 14},
+	{Color: "red", SectorId: 7, SectorNumber: 4, NumberPayout: 14},
+	{Color: "black", SectorId: 8, SectorNumber: 11, NumberPayout: 14},
+	{Color: "red", SectorId: 9, SectorNumber: 5, NumberPayout: 14},
+	{Color: "black", SectorId: 10, SectorNumber: 12, NumberPayout: 14},
+	{Color: "red", SectorId: 11, SectorNumber: 6, NumberPayout: 14},
+	{Color: "black", SectorId: 12, SectorNumber: 13, NumberPayout: 14},
+	{Color: "red", SectorId: 13, SectorNumber: 7, NumberPayout: 14},
+	{Color: "black", SectorId: 14, SectorNumber: 14, NumberPayout: 14},
+	{Color: "green", SectorId: 15, SectorNumber: 0, NumberPayout: 14},
 }
 
+const (
+	rouletteX14BettingWindow = 15 * time.Second
+	rouletteX14SpinDuration  = 5 * time.Second
+	rouletteX14NewGameDelay  = 2 * time.Second
+)
+
 var (
 	userLastBetTime      = make(map[int64]time.Time)
-	userBetPattern       = make(map[int64][]string)
 	userLastBetTimeMutex sync.Mutex
-	userBetPatternMutex  sync.Mutex
 	betCooldown          = 1 * time.Second
+
+	// Bets are placed under read lock, so closing betting
+	// waits for all in-flight bets of the round.
+	isRouletteX14BettingOpen bool
+	currentRouletteX14Round  *models.RouletteX14GameResult
+	rouletteX14RoundMutex    sync.RWMutex
 )
 
-// PlaceRouletteX14Bet handles POST requests to place a bet on the Roulette X14 game.
+var errRouletteX14BettingClosed = errors.New("betting is closed")
+
+// StartRouletteX14Game runs shared roulette rounds: betting window,
+// spin and settlement of all round bets against one RouletteX14GameResult.
+func StartRouletteX14Game() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		// Retries bets failed in previous rounds, no round is open here
+		if err := recoverRouletteX14Rounds(); err != nil {
+			logger.Error("%v", err)
+		}
+
+		round := models.RouletteX14GameResult{CreatedAt: time.Now()}
+		if err := db.DB.Create(&round).Error; err != nil {
+			logger.Error("Unable to create RouletteX14GameResult; retrying in 5 seconds: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		RouletteWebsocketService.BroadcastNewGameStarting()
+		openRouletteX14Betting(&round)
+
+		for remaining := rouletteX14BettingWindow; remaining > 0; remaining -= time.Second {
+			RouletteWebsocketService.BroadcastTimerTick(remaining, true, "betting")
+			<-ticker.C
+		}
+
+		closeRouletteX14Betting()
+
+		winningSector := spinRouletteX14Wheel()
+		bets, err := settleRouletteX14Round(&round, winningSector)
+		if err != nil {
+			logger.Error("Failed to settle roulette round %d: %v", round.ID, err)
+		}
+
+		RouletteWebsocketService.BroadcastSpinResult(winningSector)
+		refreshRouletteX14Stats()
+
+		for remaining := rouletteX14SpinDuration; remaining > 0; remaining -= time.Second {
+			RouletteWebsocketService.BroadcastTimerTick(remaining, false, "spin")
+			<-ticker.C
+		}
+
+		for _, bet := range bets {
+			RouletteWebsocketService.SendBetResultToUser(bet.UserID, bet)
+		}
+
+		time.Sleep(rouletteX14NewGameDelay)
+	}
+}
+
+func openRouletteX14Betting(round *models.RouletteX14GameResult) {
+	rouletteX14RoundMutex.Lock()
+	currentRouletteX14Round = round
+	isRouletteX14BettingOpen = true
+	rouletteX14RoundMutex.Unlock()
+}
+
+func closeRouletteX14Betting() {
+	rouletteX14RoundMutex.Lock()
+	isRouletteX14BettingOpen = false
+	rouletteX14RoundMutex.Unlock()
+}
+
+// PlaceRouletteX14Bet handles POST requests to place a bet slip
+// into the current Roulette X14 round.
 func PlaceRouletteX14Bet(c *gin.Context) {
 	var input RouletteX14BetInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -69,6 +163,20 @@ func PlaceRouletteX14Bet(c *gin.Context) {
 		return
 	}
 
+	entries := input.Entries
+	isLegacyBet := len(entries) == 0
+	if isLegacyBet {
+		if input.Color == "" || input.Amount == 0 {
+			c.JSON(400, gin.H{"error": "bet slip is empty"})
+			return
+		}
+		entries = []RouletteX14BetEntryInput{{
+			Type:   models.RouletteX14BetTypeColor,
+			Color:  input.Color,
+			Amount: input.Amount,
+		}}
+	}
+
 	userID, err := middleware.GetUserIDFromGinContext(c)
 	if err != nil {
 		logger.Error("%v", err)
@@ -82,17 +190,45 @@ func PlaceRouletteX14Bet(c *gin.Context) {
 		return
 	}
 
-	result, err := processBet(userID, input)
+	bets, err := placeRouletteX14Bets(userID, entries)
 	if err != nil {
-		if errors.Is(err, ErrInsufficientBalance) {
+		switch {
+		case errors.Is(err, ErrInsufficientBalance):
 			c.JSON(402, gin.H{"error": err.Error()})
-		} else {
+		case errors.Is(err, errRouletteX14BettingClosed):
+			c.JSON(403, gin.H{"error": err.Error()})
+		default:
 			logger.Error("%v", err)
 			c.Status(500)
 		}
 		return
 	}
 
+	// Single color bet keeps the response of the old clients
+	if isLegacyBet {
+		c.JSON(200, gin.H{
+			"round_id":       bets[0].GameResultID,
+			"bet_amount":     bets[0].Amount,
+			"bet_color":      bets[0].BetColor,
+			"is_benefit_bet": bets[0].IsBenefitBet,
+			"outcome":        bets[0].Outcome,
+		})
+		return
+	}
+
+	result := make([]gin.H, 0, len(bets))
+	for _, bet := range bets {
+		result = append(result, gin.H{
+			"round_id":       bet.GameResultID,
+			"bet_type":       bet.BetType,
+			"bet_amount":     bet.Amount,
+			"bet_color":      bet.BetColor,
+			"bet_number":     bet.BetNumber,
+			"is_benefit_bet": bet.IsBenefitBet,
+			"outcome":        bet.Outcome,
+		})
+	}
+
 	c.JSON(200, result)
 }
 
@@ -108,202 +244,315 @@ func canPlaceBet(userID int64) bool {
 	return false
 }
 
-func processBet(userID int64, input RouletteX14BetInput) (gin.H, error) {
-	var result gin.H
+// placeRouletteX14Bets charges user and stores pending bet for every
+// slip entry into the current round. Free mini-game bets are used
+// per entry while available. Slip is placed entirely or not at all.
+func placeRouletteX14Bets(userID int64, entries []RouletteX14BetEntryInput) ([]models.RouletteX14Bet, error) {
+	rouletteX14RoundMutex.RLock()
+	defer rouletteX14RoundMutex.RUnlock()
+
+	if !isRouletteX14BettingOpen || currentRouletteX14Round == nil {
+		return nil, errRouletteX14BettingClosed
+	}
+
+	var user models.User
+	bets := make([]models.RouletteX14Bet, 0, len(entries))
 
 	err := db.DB.Transaction(func(tx *gorm.DB) error {
-		var user models.User
 		if err := tx.First(&user, userID).Error; err != nil {
 			return logger.WrapError(err, "")
 		}
 
-		benefitFreeDeposit, applyBenefit, err := benefit_progress.UseFreeMiniGameBetIfAvailable(
-			tx, userID, requirements.RouletteGameID)
-		if err != nil {
-			return logger.WrapError(err, "")
-		}
-
-		var toCashBalance, toBonusBalance float64
-
-		bet := models.RouletteX14Bet{
-			UserID:       userID,
-			IsBenefitBet: benefitFreeDeposit != 0,
-			BetColor:     input.Color,
-			CreatedAt:    time.Now(),
-		}
-
-		if benefitFreeDeposit == 0 {
-			bonusBalance, err := exchange.GetUserExchangedBalanceAmount(tx, user.ID)
-			if err != nil {
-				return logger.WrapError(err, "")
+		for _, entry := range entries {
+			bet := models.RouletteX14Bet{
+				UserID:       userID,
+				GameResultID: currentRouletteX14Round.ID,
+				BetType:      entry.Type,
+				Outcome:      models.RouletteX14BetPending,
+				CreatedAt:    time.Now(),
 			}
-
-			if user.BalanceRupee+bonusBalance < input.Amount {
-				return ErrInsufficientBalance
+			if entry.Type == models.RouletteX14BetTypeColor {
+				bet.BetColor = entry.Color
+			} else {
+				number := *entry.Number
+				bet.BetNumber = &number
 			}
 
-			fromCashBalance, fromBonusBalance, err := exchange.UseExchangeBalancePayment(tx, &user, input.Amount)
+			benefitFreeDeposit, applyBenefit, err := benefit_progress.UseFreeMiniGameBetIfAvailable(
+				tx, userID, requirements.RouletteGameID)
 			if err != nil {
 				return logger.WrapError(err, "")
 			}
 
-			bet.Amount = fromCashBalance + fromBonusBalance
-			bet.FromBonusBalance = fromBonusBalance
-			bet.FromCashBalance = fromCashBalance
-		} else {
-			if err = applyBenefit(tx); err != nil {
-				return logger.WrapError(err, "")
-			}
+			bet.IsBenefitBet = benefitFreeDeposit != 0
 
-			bet.Amount = benefitFreeDeposit
-			bet.FromBonusBalance = benefitFreeDeposit
-		}
+			if benefitFreeDeposit == 0 {
+				bonusBalance, err := exchange.GetUserExchangedBalanceAmount(tx, user.ID)
+				if err != nil {
+					return logger.WrapError(err, "")
+				}
 
-		winningSector := spinRouletteX14Wheel(userID, input.Color)
-		bet.Outcome = "lose"
-		if bet.BetColor == winningSector.Color {
-			if bet.BetColor == "green" {
-				bet.Payout = bet.Amount * 14
-				toCashBalance = bet.FromCashBalance * 14
-				toBonusBalance = bet.FromBonusBalance * 14
-			} else {
-				bet.Payout = bet.Amount * 2
-				toCashBalance = bet.FromCashBalance * 2
-				toBonusBalance = bet.FromBonusBalance * 2
-			}
-			bet.Outcome = "win"
-		}
+				if user.BalanceRupee+bonusBalance < entry.Amount {
+					return ErrInsufficientBalance
+				}
 
-		if err := tx.Create(&bet).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
+				fromCashBalance, fromBonusBalance, err := exchange.UseExchangeBalancePayment(tx, &user, entry.Amount)
+				if err != nil {
+					return logger.WrapError(err, "")
+				}
 
-		// Store the game result
-		gameResult := models.RouletteX14GameResult{
-			UserID:       userID,
-			WinningColor: winningSector.Color,
-			SectorNumber: winningSector.SectorNumber,
-			CreatedAt:    time.Now(),
-		}
-		if err := tx.Create(&gameResult).Error; err != nil {
-			return logger.WrapError(err, "")
-		}
+				bet.Amount = fromCashBalance + fromBonusBalance
+				bet.FromBonusBalance = fromBonusBalance
+				bet.FromCashBalance = fromCashBalance
+			} else {
+				if err = applyBenefit(tx); err != nil {
+					return logger.WrapError(err, "")
+				}
 
-		benefitWin := bet.Outcome == "win" && bet.IsBenefitBet
-		if err = exchange.UpdateUserBalances(
-			tx, &user, toCashBalance, toBonusBalance, benefitWin); err != nil {
-			return logger.WrapError(err, "")
-		}
+				bet.Amount = benefitFreeDeposit
+				bet.FromBonusBalance = benefitFreeDeposit
+			}
 
-		// win := models.Winning{
-		// 	UserID:    user.ID,
-		// 	WinAmount: toCashBalance + toBonusBalance,
-		// }
-	
-		// if err := tx.Create(&win).Error; err != nil {
-		// 	return logger.WrapError(err, "Failed to record winning")
-		// }
-
-		if !bet.IsBenefitBet {
-			if err := updateRouletteGameTravePassLevelRequirement(
-				tx, &bet, bet.FromCashBalance); err != nil {
+			if err := tx.Create(&bet).Error; err != nil {
 				return logger.WrapError(err, "")
 			}
-		}
 
-		result = gin.H{
-			"bet_amount":     bet.Amount,
-			"bet_color":      bet.BetColor,
-			"outcome":        bet.Outcome,
-			"payout":         bet.Payout,
-			"winning_color":  winningSector.Color,
-			"winning_number": winningSector.SectorNumber,
+			err = events.Publish(tx, events.BetPlaced{
+				UserID:        userID,
+				GameID:        requirements.RouletteGameID,
+				AmountRupee:   bet.Amount,
+				FromCashRupee: bet.FromCashBalance,
+				IsBenefitBet:  bet.IsBenefitBet,
+			})
+			if err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			bets = append(bets, bet)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bet := range bets {
+		RouletteWebsocketService.BroadcastBetToAll(bet, user)
+	}
 
-	return result, err
+	return bets, nil
 }
 
-func spinRouletteX14Wheel(userID int64, betColor string) RouletteX14Sector {
-	userBetPatternMutex.Lock()
-	defer userBetPatternMutex.Unlock()
+// settleRouletteX14Round stores spin result and settles all
+// pending round bets. Returns settled bets. Round stays unsettled
+// if any bet failed, so the bet is settled by recovery.
+func settleRouletteX14Round(round *models.RouletteX14GameResult, winningSector RouletteX14Sector) ([]models.RouletteX14Bet, error) {
+	round.WinningColor = winningSector.Color
+	round.SectorNumber = winningSector.SectorNumber
+	if err := db.DB.Save(round).Error; err != nil {
+		return nil, logger.WrapError(err, "")
+	}
 
-	pattern, exists := userBetPattern[userID]
-	if !exists {
-		pattern = []string{}
+	var bets []models.RouletteX14Bet
+	if err := db.DB.Find(&bets, "game_result_id = ? AND outcome = ?",
+		round.ID, models.RouletteX14BetPending).Error; err != nil {
+		return nil, logger.WrapError(err, "")
 	}
 
-	pattern = append(pattern, betColor)
-	if len(pattern) > 5 {
-		pattern = pattern[1:]
+	settledBets := make([]models.RouletteX14Bet, 0, len(bets))
+	failed := false
+	for i := range bets {
+		// Each bet is settled separately, so one broken bet
+		// doesn't block payouts of the others
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			return settleRouletteX14Bet(tx, &bets[i], winningSector)
+		})
+		if err != nil {
+			logger.Error("Failed to settle roulette bet %d: %v", bets[i].ID, err)
+			failed = true
+			continue
+		}
+		settledBets = append(settledBets, bets[i])
 	}
-	userBetPattern[userID] = pattern
 
-	if len(pattern) == 5 && pattern[0] == "black" && pattern[1] == "red" && pattern[2] == "red" && pattern[3] == "black" {
-		// Reset pattern
-		userBetPattern[userID] = []string{}
-		// Return green sector
-		return RouletteX14Sectors[14] // Green sector
+	if failed {
+		return settledBets, nil
 	}
 
-	return RouletteX14Sectors[rand.Intn(len(RouletteX14Sectors))]
+	now := time.Now()
+	round.SettledAt = &now
+	if err := db.DB.Save(round).Error; err != nil {
+		return settledBets, logger.WrapError(err, "")
+	}
+
+	return settledBets, nil
 }
 
-func updateRouletteGameTravePassLevelRequirement(tx *gorm.DB, bet *models.RouletteX14Bet, fromCashBalance float64) error {
-	requirementMGDone, err := requirement_progress.UpdateRequirementProgressMiniGameIfRequired(
-		tx, bet.UserID, requirements.RouletteGameID, fromCashBalance, bet.Payout)
-	if err != nil {
+func settleRouletteX14Bet(tx *gorm.DB, bet *models.RouletteX14Bet, winningSector RouletteX14Sector) error {
+	var user models.User
+	if err := tx.First(&user, bet.UserID).Error; err != nil {
 		return logger.WrapError(err, "")
 	}
 
-	requirementTurnoverDone, err := requirement_progress.UpdateRequirementProgressTurnoverIfRequired(
-		tx, bet.UserID, fromCashBalance)
-	if err != nil {
+	var toCashBalance, toBonusBalance float64
+
+	bet.Outcome = models.RouletteX14BetLose
+	if multiplier := rouletteX14BetMultiplier(bet, winningSector); multiplier > 0 {
+		bet.Payout = bet.Amount * multiplier
+		toCashBalance = bet.FromCashBalance * multiplier
+		toBonusBalance = bet.FromBonusBalance * multiplier
+		bet.Outcome = models.RouletteX14BetWin
+	}
+
+	if err := tx.Save(bet).Error; err != nil {
 		return logger.WrapError(err, "")
 	}
 
-	if requirementMGDone || requirementTurnoverDone {
-		if err = travepass.CheckAndUpgradeTravePassLevel(tx, bet.UserID); err != nil {
-			return logger.WrapError(err, "")
-		}
+	benefitWin := bet.Outcome == models.RouletteX14BetWin && bet.IsBenefitBet
+	if err := exchange.UpdateUserBalances(
+		tx, &user, toCashBalance, toBonusBalance, benefitWin); err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	err := events.Publish(tx, events.BetSettled{
+		UserID:          bet.UserID,
+		GameID:          requirements.RouletteGameID,
+		AmountRupee:     bet.Amount,
+		FromCashRupee:   bet.FromCashBalance,
+		Won:             bet.Outcome == models.RouletteX14BetWin,
+		PayoutRupee:     bet.Payout,
+		CashPayoutRupee: toCashBalance,
+		IsBenefitBet:    bet.IsBenefitBet,
+	})
+	if err != nil {
+		return logger.WrapError(err, "")
 	}
 
 	return nil
 }
 
-// GetRouletteX14Info returns the information for all sectors of the Roulette X14 wheel.
-func GetRouletteX14Info(c *gin.Context) {
-	c.JSON(200, RouletteX14Sectors)
+// rouletteX14BetMultiplier returns payout multiplier of the bet
+// for given winning sector, or zero if bet lost.
+func rouletteX14BetMultiplier(bet *models.RouletteX14Bet, winningSector RouletteX14Sector) float64 {
+	switch bet.BetType {
+	case models.RouletteX14BetTypeNumber:
+		if bet.BetNumber != nil && *bet.BetNumber == winningSector.SectorNumber {
+			return winningSector.NumberPayout
+		}
+	default:
+		if bet.BetColor == winningSector.Color {
+			return RouletteX14ColorPayouts[bet.BetColor]
+		}
+	}
+	return 0
 }
 
-func GetRouletteX14History(c *gin.Context) {
-	userID, err := middleware.GetUserIDFromGinContext(c)
-	if err != nil {
-		logger.Error("%v", err)
-		c.Status(500)
-		return
+// recoverRouletteX14Rounds finishes rounds left unsettled by restart
+// or by failed bets. Spun rounds are settled against their result,
+// stakes of rounds that were not spun are returned.
+func recoverRouletteX14Rounds() error {
+	var rounds []models.RouletteX14GameResult
+	if err := db.DB.Find(&rounds, "settled_at IS NULL").Error; err != nil {
+		return logger.WrapError(err, "")
 	}
 
-	var history []models.RouletteX14GameResult
-	err = db.DB.Transaction(func(tx *gorm.DB) error {
-		// Fetch the latest 20 results
-		if err := tx.Where("user_id = ?", userID).Order("created_at desc").Limit(20).Find(&history).Error; err != nil {
-			return err
+	for i := range rounds {
+		if rounds[i].WinningColor != "" {
+			for _, sector := range RouletteX14Sectors {
+				if sector.SectorNumber == rounds[i].SectorNumber {
+					if _, err := settleRouletteX14Round(&rounds[i], sector); err != nil {
+						logger.Error("%v", err)
+					}
+					break
+				}
+			}
+			continue
 		}
 
-		// If we have 20 results, delete older ones
-		if len(history) == 20 {
-			oldestTimestamp := history[len(history)-1].CreatedAt
-			if err := tx.Where("user_id = ? AND created_at < ?", userID, oldestTimestamp).Delete(&models.RouletteX14GameResult{}).Error; err != nil {
-				return err
+		if err := refundRouletteX14Round(&rounds[i]); err != nil {
+			logger.Error("%v", err)
+		}
+	}
+
+	return nil
+}
+
+// refundRouletteX14Round refunds pending bets of interrupted round.
+// Stake is returned to balances it was paid from, free bet is
+// given back as free bet. Round stays unsettled if any
+// refund failed.
+func refundRouletteX14Round(round *models.RouletteX14GameResult) error {
+	var bets []models.RouletteX14Bet
+	if err := db.DB.Find(&bets, "game_result_id = ? AND outcome = ?",
+		round.ID, models.RouletteX14BetPending).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	failed := false
+	for i := range bets {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var user models.User
+			if err := tx.First(&user, bets[i].UserID).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			bets[i].Outcome = models.RouletteX14BetRefund
+			bets[i].Payout = bets[i].Amount
+			if err := tx.Save(&bets[i]).Error; err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			// Free bet is given back instead of its deposit
+			if bets[i].IsBenefitBet {
+				err := benefit_progress.RestoreFreeMiniGameBet(tx, user.ID,
+					requirements.RouletteGameID, bets[i].Amount)
+				if err != nil {
+					return logger.WrapError(err, "")
+				}
+				return nil
 			}
+
+			if err := exchange.UpdateUserBalances(tx, &user, bets[i].FromCashBalance,
+				bets[i].FromBonusBalance, false); err != nil {
+				return logger.WrapError(err, "")
+			}
+
+			return nil
+		})
+		if err != nil {
+			logger.Error("Failed to refund roulette bet %d: %v", bets[i].ID, err)
+			failed = true
 		}
+	}
 
+	if failed {
 		return nil
-	})
+	}
+
+	now := time.Now()
+	round.SettledAt = &now
+	if err := db.DB.Save(round).Error; err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
 
+func spinRouletteX14Wheel() RouletteX14Sector {
+	return RouletteX14Sectors[rand.Intn(len(RouletteX14Sectors))]
+}
+
+// GetRouletteX14Info returns the information for all sectors of the Roulette X14 wheel.
+func GetRouletteX14Info(c *gin.Context) {
+	c.JSON(200, RouletteX14Sectors)
+}
+
+// GetRouletteX14History returns results of the latest 20 spun rounds.
+func GetRouletteX14History(c *gin.Context) {
+	var history []models.RouletteX14GameResult
+	err := db.DB.Where("winning_color <> ''").
+		Order("id desc").Limit(20).Find(&history).Error
 	if err != nil {
 		logger.Error("%v", err)
 		c.Status(500)
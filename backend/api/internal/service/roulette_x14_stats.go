@@ -0,0 +1,207 @@
+package service
+
+import (
+	"BlessedApi/cmd/db"
+	"BlessedApi/internal/models"
+	"BlessedApi/pkg/logger"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Redis hash with cached RouletteX14Stats, field is window size
+	rouletteX14StatsKey = "roulette_x14:stats"
+
+	rouletteX14StatsDefaultWindow = 100
+	rouletteX14StatsMinWindow     = 10
+	rouletteX14StatsMaxWindow     = 1000
+
+	// Number of hot and cold numbers in RouletteX14Stats
+	rouletteX14StatsHotColdCount = 3
+)
+
+type RouletteX14StatsResult struct {
+	RoundID      int64  `json:"round_id"`
+	Color        string `json:"color"`
+	SectorNumber int    `json:"sector_number"`
+}
+
+type RouletteX14Streak struct {
+	Color  string `json:"color"`
+	Length int    `json:"length"`
+}
+
+type RouletteX14SectorHits struct {
+	SectorNumber int    `json:"sector_number"`
+	Color        string `json:"color"`
+	Hits         int    `json:"hits"`
+}
+
+// RouletteX14Stats aggregates the latest Window spun rounds.
+// Results are ordered from the newest to the oldest.
+type RouletteX14Stats struct {
+	Window           int                      `json:"window"`
+	Rounds           int                      `json:"rounds"`
+	Results          []RouletteX14StatsResult `json:"results"`
+	ColorCounts      map[string]int           `json:"color_counts"`
+	ColorFrequencies map[string]float64       `json:"color_frequencies"`
+	CurrentStreak    RouletteX14Streak        `json:"current_streak"`
+	LongestStreaks   map[string]int           `json:"longest_streaks"`
+	SectorHits       []RouletteX14SectorHits  `json:"sector_hits"`
+	HotNumbers       []int                    `json:"hot_numbers"`
+	ColdNumbers      []int                    `json:"cold_numbers"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+// GetRouletteX14Stats returns statistics of the latest rounds.
+// Window is set by "window" query parameter, 100 by default.
+func GetRouletteX14Stats(c *gin.Context) {
+	window, err := strconv.Atoi(c.DefaultQuery("window",
+		strconv.Itoa(rouletteX14StatsDefaultWindow)))
+	if err != nil || window < rouletteX14StatsMinWindow || window > rouletteX14StatsMaxWindow {
+		c.JSON(400, gin.H{"error": "window must be a number between " +
+			strconv.Itoa(rouletteX14StatsMinWindow) + " and " +
+			strconv.Itoa(rouletteX14StatsMaxWindow)})
+		return
+	}
+
+	stats, err := getRouletteX14Stats(window)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, stats)
+}
+
+// getRouletteX14Stats returns cached stats of given window,
+// calculating and caching them on miss.
+func getRouletteX14Stats(window int) (*RouletteX14Stats, error) {
+	ctx := context.Background()
+	field := strconv.Itoa(window)
+
+	if redisService != nil {
+		cached, err := redisService.GetHashField(ctx, rouletteX14StatsKey, field)
+		if err == nil {
+			var stats RouletteX14Stats
+			if err := json.Unmarshal([]byte(cached), &stats); err == nil {
+				return &stats, nil
+			}
+		}
+	}
+
+	stats, err := calculateRouletteX14Stats(window)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	if redisService != nil {
+		statsJSON, err := json.Marshal(stats)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+
+		if err := redisService.SetHashField(ctx, rouletteX14StatsKey, field, string(statsJSON)); err != nil {
+			logger.Warn("%v", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// refreshRouletteX14Stats drops cached stats of all windows after
+// spin and warms the default one.
+func refreshRouletteX14Stats() {
+	if redisService == nil {
+		return
+	}
+
+	if err := redisService.DeleteKey(context.Background(), rouletteX14StatsKey); err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	if _, err := getRouletteX14Stats(rouletteX14StatsDefaultWindow); err != nil {
+		logger.Error("%v", err)
+	}
+}
+
+func calculateRouletteX14Stats(window int) (*RouletteX14Stats, error) {
+	var rounds []models.RouletteX14GameResult
+	err := db.DB.Where("winning_color <> ''").
+		Order("id desc").Limit(window).Find(&rounds).Error
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	stats := RouletteX14Stats{
+		Window:           window,
+		Rounds:           len(rounds),
+		Results:          make([]RouletteX14StatsResult, 0, len(rounds)),
+		ColorCounts:      map[string]int{"red": 0, "black": 0, "green": 0},
+		ColorFrequencies: map[string]float64{"red": 0, "black": 0, "green": 0},
+		LongestStreaks:   map[string]int{"red": 0, "black": 0, "green": 0},
+		UpdatedAt:        time.Now(),
+	}
+
+	sectorHits := make(map[int]int)
+	streak := RouletteX14Streak{}
+	for i, round := range rounds {
+		stats.Results = append(stats.Results, RouletteX14StatsResult{
+			RoundID:      round.ID,
+			Color:        round.WinningColor,
+			SectorNumber: round.SectorNumber,
+		})
+		stats.ColorCounts[round.WinningColor]++
+		sectorHits[round.SectorNumber]++
+
+		if round.WinningColor == streak.Color {
+			streak.Length++
+		} else {
+			streak = RouletteX14Streak{Color: round.WinningColor, Length: 1}
+		}
+
+		// Rounds are ordered from the newest, so streak
+		// started from the first round is the current one
+		if streak.Length == i+1 {
+			stats.CurrentStreak = streak
+		}
+
+		if streak.Length > stats.LongestStreaks[streak.Color] {
+			stats.LongestStreaks[streak.Color] = streak.Length
+		}
+	}
+
+	if len(rounds) != 0 {
+		for color, count := range stats.ColorCounts {
+			stats.ColorFrequencies[color] = float64(count) / float64(len(rounds)) * 100
+		}
+	}
+
+	for _, sector := range RouletteX14Sectors {
+		stats.SectorHits = append(stats.SectorHits, RouletteX14SectorHits{
+			SectorNumber: sector.SectorNumber,
+			Color:        sector.Color,
+			Hits:         sectorHits[sector.SectorNumber],
+		})
+	}
+
+	byHits := make([]RouletteX14SectorHits, len(stats.SectorHits))
+	copy(byHits, stats.SectorHits)
+	sort.SliceStable(byHits, func(i, j int) bool {
+		return byHits[i].Hits > byHits[j].Hits
+	})
+
+	for i := 0; i < rouletteX14StatsHotColdCount && i < len(byHits); i++ {
+		stats.HotNumbers = append(stats.HotNumbers, byHits[i].SectorNumber)
+		stats.ColdNumbers = append(stats.ColdNumbers, byHits[len(byHits)-1-i].SectorNumber)
+	}
+
+	return &stats, nil
+}
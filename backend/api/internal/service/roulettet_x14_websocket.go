@@ -14,29 +14,40 @@ import (
 // Exported global instance of the WebSocket service
 var RouletteWebsocketService *RouletteX14WebsocketService
 
-//func init() {
-//	RouletteWebsocketService = NewRouletteX14WebsocketService()
-//}
+func init() {
+	RouletteWebsocketService = NewRouletteX14WebsocketService()
+}
 
 // RouletteX14WebsocketService handles WebSocket connections for the Roulette X14 game.
 type RouletteX14WebsocketService struct {
 	connections      map[int64]*websocket.Conn
 	mu               sync.Mutex
 	lastActivityTime map[int64]time.Time
-	betDistribution  map[string]float64
-	betMutex         sync.RWMutex
+	// Total amounts of current round bets by color
+	betAmounts map[string]float64
+	// Percentage of betAmounts by color
+	betDistribution map[string]float64
+	betMutex        sync.RWMutex
 }
 
 func (ws *RouletteX14WebsocketService) updateBetDistribution(bet models.RouletteX14Bet) {
+	// Distribution is shown by colors only
+	if bet.BetType == models.RouletteX14BetTypeNumber {
+		return
+	}
+
 	ws.betMutex.Lock()
 	defer ws.betMutex.Unlock()
 
-	ws.betDistribution[bet.BetColor] += bet.Amount
+	ws.betAmounts[bet.BetColor] += bet.Amount
 
-	total := ws.betDistribution["red"] + ws.betDistribution["black"] + ws.betDistribution["green"]
+	total := ws.betAmounts["red"] + ws.betAmounts["black"] + ws.betAmounts["green"]
+	if total == 0 {
+		return
+	}
 
-	for color := range ws.betDistribution {
-		ws.betDistribution[color] = (ws.betDistribution[color] / total) * 100
+	for color, amount := range ws.betAmounts {
+		ws.betDistribution[color] = (amount / total) * 100
 	}
 }
 
@@ -45,12 +56,8 @@ func NewRouletteX14WebsocketService() *RouletteX14WebsocketService {
 	service := &RouletteX14WebsocketService{
 		connections:      make(map[int64]*websocket.Conn),
 		lastActivityTime: make(map[int64]time.Time),
-		betDistribution: map[string]float64{
-			"red":   0,
-			"black": 0,
-			"green": 0,
-		},
 	}
+	service.resetBetDistribution()
 	go service.cleanupInactiveConnections()
 	return service
 }
@@ -89,7 +96,7 @@ func SuperviseRouletteX14Game() {
 				}
 			}()
 
-			//StartRouletteX14Game()
+			StartRouletteX14Game()
 		}()
 
 		// Wait for the game loop to finish (which should only happen if there's a panic)
@@ -167,12 +174,16 @@ func (ws *RouletteX14WebsocketService) BroadcastBetToAll(bet models.RouletteX14B
 	ws.updateBetDistribution(bet)
 
 	betInfo := gin.H{
+		"type":             "new_bet",
 		"user_id":          user.ID,
 		"nickname":         user.Nickname,
 		"avatar_id":        user.AvatarID,
 		"amount":           bet.Amount,
+		"bet_type":         bet.BetType,
 		"bet_color":        bet.BetColor,
+		"bet_number":       bet.BetNumber,
 		"bet_distribution": ws.betDistribution,
+		"bet_amounts":      ws.betAmounts,
 	}
 
 	for _, conn := range ws.connections {
@@ -188,6 +199,11 @@ func (ws *RouletteX14WebsocketService) resetBetDistribution() {
 	ws.betMutex.Lock()
 	defer ws.betMutex.Unlock()
 
+	ws.betAmounts = map[string]float64{
+		"red":   0,
+		"black": 0,
+		"green": 0,
+	}
 	ws.betDistribution = map[string]float64{
 		"red":   0,
 		"black": 0,
@@ -202,10 +218,14 @@ func (ws *RouletteX14WebsocketService) SendBetResultToUser(userId int64, bet mod
 
 	if conn, ok := ws.connections[userId]; ok {
 		resultInfo := gin.H{
-			"amount":    bet.Amount,
-			"bet_color": bet.BetColor,
-			"outcome":   bet.Outcome,
-			"payout":    bet.Payout,
+			"type":       "bet_result",
+			"round_id":   bet.GameResultID,
+			"amount":     bet.Amount,
+			"bet_type":   bet.BetType,
+			"bet_color":  bet.BetColor,
+			"bet_number": bet.BetNumber,
+			"outcome":    bet.Outcome,
+			"payout":     bet.Payout,
 		}
 		err := conn.WriteJSON(resultInfo)
 		if err != nil {
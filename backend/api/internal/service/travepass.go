@@ -6,12 +6,66 @@ import (
 	"BlessedApi/internal/models"
 	"BlessedApi/internal/models/travepass"
 	"BlessedApi/pkg/logger"
+	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// getUserTravePassLevel moves user to the active season and returns
+// user current level, nil if user is out of any season.
+func getUserTravePassLevel(userID int64) (*travepass.TravePassLevel, error) {
+	var level *travepass.TravePassLevel
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := travepass.EnsureUserTravePassSeason(tx, userID); err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		var levelID int64
+		err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Pluck("trave_pass_level_id", &levelID).Error
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		level, err = travepass.GetTravePassLevel(tx, levelID)
+		if err != nil {
+			return logger.WrapError(err, "")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return level, nil
+}
+
+// GetAllTravePassLevelsWithRequirementsAndBenefits returns
+// levels of the user current season.
 func GetAllTravePassLevelsWithRequirementsAndBenefits(c *gin.Context) {
-	levels, err := travepass.GetAllTravePassLevelsWithRequirementsAndBenefits(nil)
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	level, err := getUserTravePassLevel(userID)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if level == nil {
+		c.String(404, "[]")
+		return
+	}
+
+	levels, err := travepass.GetAllTravePassLevelsWithRequirementsAndBenefits(nil, level.SeasonID)
 	if err != nil {
 		logger.Error("%v", err)
 		c.Status(500)
@@ -35,28 +89,39 @@ func GetNextLevelRequirements(c *gin.Context) {
 		return
 	}
 
-	var travePassNextLevelID int64
-	err = db.DB.Model(&models.User{}).
-		Where("id = ?", userID).
-		Pluck("trave_pass_level_id", &travePassNextLevelID).Error
+	level, err := getUserTravePassLevel(userID)
 	if err != nil {
 		logger.Error("%v", err)
 		c.Status(500)
 		return
 	}
 
-	// Get user next level
-	travePassNextLevelID++
+	if level == nil {
+		c.String(404, "[]")
+		return
+	}
+
+	nextLevel, err := level.NextLevel(nil)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	// Last level of the season reached
+	if nextLevel == nil {
+		c.String(404, "[]")
+		return
+	}
 
 	var reqs []travepass.TravePassLevelRequirement
-	err = db.DB.Preload("Requirement").Find(&reqs, "trave_pass_level_id = ?", travePassNextLevelID).Error
+	err = db.DB.Preload("Requirement").Find(&reqs, "trave_pass_level_id = ?", nextLevel.ID).Error
 	if err != nil {
 		logger.Error("%v", err)
 		c.Status(500)
 		return
 	}
 
-	// Can only happen on 60lvl
 	if len(reqs) == 0 {
 		c.String(404, "[]")
 		return
@@ -73,3 +138,89 @@ func GetNextLevelRequirements(c *gin.Context) {
 
 	c.JSON(200, reqs)
 }
+
+// GetUserTravePassSeasons returns user progress of the current
+// and archived seasons, the latest season first.
+func GetUserTravePassSeasons(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if _, err = getUserTravePassLevel(userID); err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	progresses, err := travepass.GetUserTravePassSeasonProgresses(nil, userID)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	c.JSON(200, progresses)
+}
+
+// GetUnseenTravePassReachedLevels returns levels with benefits user
+// reached since the previous request, so client can show level-ups.
+func GetUnseenTravePassReachedLevels(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var levels []travepass.TravePassLevel
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		levels, err = travepass.GetUnseenTravePassReachedLevels(tx, userID)
+		return err
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	if len(levels) == 0 {
+		c.String(404, "[]")
+		return
+	}
+
+	c.JSON(200, levels)
+}
+
+// BuyTravePassPremium buys premium pass of the active season
+// from user cash balance.
+func BuyTravePassPremium(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromGinContext(c)
+	if err != nil {
+		logger.Error("%v", err)
+		c.Status(500)
+		return
+	}
+
+	var progress *travepass.TravePassSeasonProgress
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		progress, err = travepass.BuyTravePassPremium(tx, userID)
+		return err
+	})
+	switch {
+	case err == nil:
+		c.JSON(200, progress)
+	case errors.Is(err, travepass.ErrInsufficientBalance):
+		c.JSON(402, gin.H{"error": err.Error()})
+	case errors.Is(err, travepass.ErrNoActiveSeason):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, travepass.ErrPremiumUnavailable),
+		errors.Is(err, travepass.ErrPremiumAlreadyBought):
+		c.JSON(409, gin.H{"error": err.Error()})
+	default:
+		logger.Error("%v", err)
+		c.Status(500)
+	}
+}
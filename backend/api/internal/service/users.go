@@ -89,8 +89,7 @@ func SignUp(c *gin.Context) {
 			}
 		}
 
-		if err = travepass.CreateUserRequirementProgresses(
-			tx, user.ID, user.TravePassLevelID+1); err != nil {
+		if err = travepass.StartUserTravePass(tx, user.ID); err != nil {
 			return logger.WrapError(err, "")
 		}
 		return nil
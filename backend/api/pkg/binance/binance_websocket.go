@@ -1,66 +1,157 @@
 package binance
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 
 	"BlessedApi/pkg/logger"
-	"BlessedApi/pkg/redis"
+	"BlessedApi/pkg/pricefeed"
 )
 
-type KlineData struct {
-	OpenTime                 int64   `json:"openTime"`
-	Open                     float64 `json:"open"`
-	High                     float64 `json:"high"`
-	Low                      float64 `json:"low"`
-	Close                    float64 `json:"close"`
-	Volume                   float64 `json:"volume"`
-	CloseTime                int64   `json:"closeTime"`
-	QuoteAssetVolume         float64 `json:"quoteAssetVolume"`
-	NumberOfTrades           int64   `json:"numberOfTrades"`
-	TakerBuyBaseAssetVolume  float64 `json:"takerBuyBaseAssetVolume"`
-	TakerBuyQuoteAssetVolume float64 `json:"takerBuyQuoteAssetVolume"`
-}
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = time.Minute
+	// Kline_1s stream sends event every second, so connection
+	// silent longer than readTimeout is considered dead
+	readTimeout = 15 * time.Second
+)
 
+// BinanceWebsocketService is pricefeed.Feed of live Binance klines.
 type BinanceWebsocketService struct {
-	redisService *redis.RedisService
-	wsConn       *websocket.Conn
+	store *pricefeed.KlineStore
+	// Upper case symbols, e.g. BTCUSDT
+	symbols []string
+
+	mu       sync.Mutex
+	wsConn   *websocket.Conn
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-func NewBinanceWebsocketService(redisService *redis.RedisService) *BinanceWebsocketService {
+func NewBinanceWebsocketService(store *pricefeed.KlineStore, symbols []string) *BinanceWebsocketService {
 	return &BinanceWebsocketService{
-		redisService: redisService,
+		store:   store,
+		symbols: symbols,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start subscribes to 1s klines of all service symbols in background.
+// Connection is reestablished with jittered exponential backoff
+// until Stop is called.
+func (b *BinanceWebsocketService) Start() error {
+	if len(b.symbols) == 0 {
+		return logger.WrapError(fmt.Errorf("binance feed has no symbols"), "")
+	}
+
+	go b.run()
+	return nil
+}
+
+// Stop closes connection and stops reconnecting.
+// Calls after the first one do nothing.
+func (b *BinanceWebsocketService) Stop() {
+	b.stopOnce.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		close(b.stop)
+		if b.wsConn == nil {
+			return
+		}
+
+		if err := b.wsConn.Close(); err != nil {
+			logger.Error("%v", err)
+		}
+	})
+}
+
+func (b *BinanceWebsocketService) run() {
+	attempt := 0
+	for {
+		conn, err := b.connect()
+		if err == nil {
+			attempt = 0
+			b.readMessages(conn)
+		} else {
+			logger.Error("%v", err)
+		}
+
+		delay := reconnectBackoff(attempt)
+		attempt++
+		logger.Warn("Reconnecting to Binance WebSocket in %v", delay)
+
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(delay):
+		}
 	}
 }
 
-func (b *BinanceWebsocketService) Start() {
-	u := url.URL{Scheme: "wss", Host: "stream.binance.com:9443", Path: "/ws/btcusdt@kline_1s"}
+// reconnectBackoff returns exponential delay of reconnect attempt
+// with random jitter of up to its half, so that instances don't
+// reconnect all at once.
+func reconnectBackoff(attempt int) time.Duration {
+	delay := reconnectMaxBackoff
+	if attempt < 16 && reconnectMinBackoff<<attempt < reconnectMaxBackoff {
+		delay = reconnectMinBackoff << attempt
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// connect dials Binance combined stream of service symbols.
+func (b *BinanceWebsocketService) connect() (*websocket.Conn, error) {
+	streams := make([]string, 0, len(b.symbols))
+	for _, symbol := range b.symbols {
+		streams = append(streams, strings.ToLower(symbol)+"@kline_1s")
+	}
+
+	u := url.URL{
+		Scheme:   "wss",
+		Host:     "stream.binance.com:9443",
+		Path:     "/stream",
+		RawQuery: "streams=" + strings.Join(streams, "/"),
+	}
 	logger.Info("Connecting to Binance WebSocket at %s", u.String())
 
 	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
 	if err != nil {
-		logger.Fatal("%v", err)
+		return nil, logger.WrapError(err, "")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.stop:
+		conn.Close()
+		return nil, logger.WrapError(fmt.Errorf("binance feed is stopped"), "")
+	default:
 	}
 
 	b.wsConn = conn
 	logger.Info("Connected to Binance WebSocket.")
 
 	// Set ping/pong handlers
-	b.setupPingPongHandlers()
+	setupPingPongHandlers(conn)
 
-	go b.readMessages()
+	return conn, nil
 }
 
-func (b *BinanceWebsocketService) setupPingPongHandlers() {
+func setupPingPongHandlers(conn *websocket.Conn) {
 	// Handle incoming ping frames by replying with a pong frame
-	b.wsConn.SetPingHandler(func(appData string) error {
-		err := b.wsConn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(time.Second*10))
+	conn.SetPingHandler(func(appData string) error {
+		err := conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(time.Second*10))
 		if err != nil {
 			logger.Error("%v", err)
 			return logger.WrapError(err, "")
@@ -69,21 +160,34 @@ func (b *BinanceWebsocketService) setupPingPongHandlers() {
 	})
 }
 
-func (b *BinanceWebsocketService) readMessages() {
+// readMessages stores klines until connection fails or is silent
+// for readTimeout.
+func (b *BinanceWebsocketService) readMessages(conn *websocket.Conn) {
+	defer conn.Close()
+
 	for {
-		_, message, err := b.wsConn.ReadMessage()
+		if err := conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			logger.Error("%v", err)
+			return
+		}
+
+		_, message, err := conn.ReadMessage()
 		if err != nil {
 			logger.Error("%v", err)
 			return
 		}
 
-		var event map[string]interface{}
-		if err := json.Unmarshal(message, &event); err != nil {
+		// Combined stream wraps events into {"stream": ..., "data": ...}
+		var combinedEvent struct {
+			Stream string                 `json:"stream"`
+			Data   map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(message, &combinedEvent); err != nil {
 			logger.Error("%v", err)
 			continue
 		}
 
-		err = b.handleKlineEvent(event)
+		err = b.handleKlineEvent(combinedEvent.Data)
 		if err != nil {
 			logger.Error("%v", err)
 			continue
@@ -97,6 +201,11 @@ func (b *BinanceWebsocketService) handleKlineEvent(event map[string]interface{})
 		return logger.WrapError(fmt.Errorf("unable to cast kline: %v", event["k"]), "")
 	}
 
+	symbol, ok := kline["s"].(string)
+	if !ok {
+		return logger.WrapError(fmt.Errorf("unable to cast kline symbol: %v", kline["s"]), "")
+	}
+
 	openTime := int64(kline["t"].(float64))
 	closeTime := int64(kline["T"].(float64))
 
@@ -142,7 +251,8 @@ func (b *BinanceWebsocketService) handleKlineEvent(event map[string]interface{})
 		return logger.WrapError(err, "")
 	}
 
-	data := KlineData{
+	data := pricefeed.KlineData{
+		Symbol:                   symbol,
 		OpenTime:                 openTime,
 		Open:                     open,
 		High:                     high,
@@ -156,53 +266,9 @@ func (b *BinanceWebsocketService) handleKlineEvent(event map[string]interface{})
 		TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
 	}
 
-	err = b.storeKlineData(data)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-	return nil
-}
-
-func (b *BinanceWebsocketService) storeKlineData(data KlineData) error {
-	ctx := context.Background()
-	key := fmt.Sprintf("binance_kline_%d", data.OpenTime)
-	dataBytes, err := json.Marshal(data)
+	err = b.store.Store(data)
 	if err != nil {
 		return logger.WrapError(err, "")
 	}
-
-	// Store the new Kline data
-	err = b.redisService.SetKey(ctx, key, dataBytes, 5*time.Minute)
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-
-	// Fetch all keys matching the kline data pattern to get the count
-	keys, err := b.redisService.Client().Keys(ctx, "binance_kline_*").Result()
-	if err != nil {
-		return logger.WrapError(err, "")
-	}
-
-	// Calculate the timestamp 5 minutes ago
-	cutoffTime := data.OpenTime - (5 * time.Minute).Milliseconds()
-
-	// Remove any data older than 5 minutes
-	for _, k := range keys {
-		// Extract timestamp from key
-		tsStr := k[len("binance_kline_"):]
-		ts, err := strconv.ParseInt(tsStr, 10, 64)
-		if err != nil {
-			logger.Warn("Unable to extract timestamp from key %v", err)
-			continue
-		}
-
-		// If the data is older than 5 minutes, delete the key
-		if ts < cutoffTime {
-			err := b.redisService.Client().Del(ctx, k).Err()
-			if err != nil {
-				return logger.WrapError(err, "")
-			}
-		}
-	}
 	return nil
 }
@@ -7,17 +7,26 @@ import (
 	"BlessedApi/internal/models/benefits/benefit_progress"
 	"BlessedApi/internal/models/exchange"
 	"BlessedApi/internal/models/fortune_wheel"
+	"BlessedApi/internal/models/items"
+	"BlessedApi/internal/models/quests"
 	"BlessedApi/internal/models/requirements"
 	"BlessedApi/internal/models/requirements/requirement_progress"
 	"BlessedApi/internal/models/travepass"
 	"BlessedApi/pkg/logger"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 func main() {
 	// dropTables()
 	// createTables()
-	// seedTravepass()
+	// Set end date of the legacy season before running
+	// migrateTravePassSeasons(time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC))
+	// migrateTravePassReachedLevels()
+	// migrateBenefitItems()
+	// migrateBenefitProgressesExpiry()
+	// seedTravepass("Season 1", time.Now(), time.Now().AddDate(0, 3, 0))
 	// seedFortuneWheelBenefits()
 
 	logger.Info("Migrated.")
@@ -28,21 +37,38 @@ func dropTables() {
 		&models.User{},
 		&models.Deposit{},
 		&models.BinaryBet{},
+		&models.BinaryBetSellQuote{},
 		&models.UserReferral{},
 		&models.RouletteX14Bet{},
 		&models.RouletteX14GameResult{},
 		&models.CrashGameBet{},
 		&models.CrashGame{},
+		&models.CrashGameAutoBet{},
+		&models.PriceFeedGap{},
+		&models.PriceKline{},
+		&models.PriceCandle{},
 		&models.Withdrawal{},
 
 		&exchange.ExchangeBalance{},
 
+		&travepass.TravePassSeason{},
+		&travepass.TravePassSeasonProgress{},
 		&travepass.TravePassLevel{},
 		&travepass.TravePassLevelRequirement{},
 		&travepass.TravePassLevelBenefit{},
+		&travepass.TravePassReachedLevel{},
 
 		&fortune_wheel.FortuneWheelSector{},
 
+		&items.Item{},
+		&items.UserItem{},
+		&items.UserItemAcquisition{},
+		&items.ItemFulfilment{},
+
+		&quests.Quest{},
+		&quests.QuestBenefit{},
+		&quests.UserQuest{},
+
 		&requirements.Requirement{},
 		&requirements.RequirementBinaryOption{},
 		&requirements.RequirementClicker{},
@@ -50,6 +76,7 @@ func dropTables() {
 		&requirements.RequirementMiniGame{},
 		&requirements.RequirementReplenishment{},
 		&requirements.RequirementTurnover{},
+		&requirements.RequirementCrashGame{},
 
 		&requirement_progress.RequirementProgress{},
 		&requirement_progress.RequirementProgressClicker{},
@@ -58,6 +85,7 @@ func dropTables() {
 		&requirement_progress.RequirementProgressMiniGame{},
 		&requirement_progress.RequirementProgressReplenishment{},
 		&requirement_progress.RequirementProgressTurnover{},
+		&requirement_progress.RequirementProgressCrashGame{},
 
 		&benefits.Benefit{},
 		&benefits.BenefitFortuneWheel{},
@@ -74,6 +102,7 @@ func dropTables() {
 		&benefit_progress.BenefitProgressFortuneWheel{},
 		&benefit_progress.BenefitProgressMiniGame{},
 		&benefit_progress.BenefitProgressReplenishment{},
+		&benefit_progress.ExpiredBenefitProgress{},
 	)
 }
 
@@ -82,21 +111,38 @@ func createTables() {
 		&models.User{},
 		&models.Deposit{},
 		&models.BinaryBet{},
+		&models.BinaryBetSellQuote{},
 		&models.UserReferral{},
 		&models.RouletteX14Bet{},
 		&models.RouletteX14GameResult{},
 		&models.CrashGameBet{},
 		&models.CrashGame{},
+		&models.CrashGameAutoBet{},
+		&models.PriceFeedGap{},
+		&models.PriceKline{},
+		&models.PriceCandle{},
 		&models.Withdrawal{},
 
 		&exchange.ExchangeBalance{},
 
+		&travepass.TravePassSeason{},
+		&travepass.TravePassSeasonProgress{},
 		&travepass.TravePassLevel{},
 		&travepass.TravePassLevelRequirement{},
 		&travepass.TravePassLevelBenefit{},
+		&travepass.TravePassReachedLevel{},
 
 		&fortune_wheel.FortuneWheelSector{},
 
+		&items.Item{},
+		&items.UserItem{},
+		&items.UserItemAcquisition{},
+		&items.ItemFulfilment{},
+
+		&quests.Quest{},
+		&quests.QuestBenefit{},
+		&quests.UserQuest{},
+
 		&requirements.Requirement{},
 		&requirements.RequirementBinaryOption{},
 		&requirements.RequirementClicker{},
@@ -104,6 +150,7 @@ func createTables() {
 		&requirements.RequirementMiniGame{},
 		&requirements.RequirementReplenishment{},
 		&requirements.RequirementTurnover{},
+		&requirements.RequirementCrashGame{},
 
 		&requirement_progress.RequirementProgress{},
 		&requirement_progress.RequirementProgressClicker{},
@@ -112,6 +159,7 @@ func createTables() {
 		&requirement_progress.RequirementProgressMiniGame{},
 		&requirement_progress.RequirementProgressReplenishment{},
 		&requirement_progress.RequirementProgressTurnover{},
+		&requirement_progress.RequirementProgressCrashGame{},
 
 		&benefits.Benefit{},
 		&benefits.BenefitFortuneWheel{},
@@ -128,19 +176,109 @@ func createTables() {
 		&benefit_progress.BenefitProgressFortuneWheel{},
 		&benefit_progress.BenefitProgressMiniGame{},
 		&benefit_progress.BenefitProgressReplenishment{},
+		&benefit_progress.ExpiredBenefitProgress{},
 	)
 }
 
-func seedTravepass() {
-	applyLevels()
+// seedTravepass creates new season with the default level ladder.
+// Existing seasons and user progress are kept, users move to the
+// new season once it starts.
+func seedTravepass(name string, startsAt, endsAt time.Time) {
+	applyLevels(name, startsAt, endsAt)
 	applyTravePassLevelRequirements()
 	applyTravePassLevelBenefits()
 }
 
-func applyLevels() {
-	db.DB.Exec("INSERT INTO trave_pass_levels (id) VALUES (?)", 0)
-	for i := 1; i <= 60; i++ {
-		db.DB.Create(&travepass.TravePassLevel{})
+// seedLevelIDs maps level number of the seeded season to level id
+var seedLevelIDs = map[int64]int64{}
+
+func applyLevels(name string, startsAt, endsAt time.Time) {
+	season := travepass.TravePassSeason{
+		Name: name, StartsAt: startsAt, EndsAt: endsAt}
+	if err := travepass.CreateTravePassSeason(nil, &season, 60); err != nil {
+		logger.Fatal("%v", err)
+	}
+
+	for _, level := range season.Levels {
+		seedLevelIDs[level.Number] = level.ID
+	}
+}
+
+// migrateTravePassSeasons moves levels created before seasons
+// into the first season ending at endsAt, level number is taken
+// from level id.
+func migrateTravePassSeasons(endsAt time.Time) {
+	var seasonsCount int64
+	if err := db.DB.Model(&travepass.TravePassSeason{}).Count(&seasonsCount).Error; err != nil {
+		logger.Fatal("%v", err)
+	}
+	if seasonsCount != 0 {
+		return
+	}
+
+	season := travepass.TravePassSeason{
+		Name: "Season 1", StartsAt: time.Now(), EndsAt: endsAt}
+	if err := db.DB.Create(&season).Error; err != nil {
+		logger.Fatal("%v", err)
+	}
+
+	err := db.DB.Model(&travepass.TravePassLevel{}).
+		Where("season_id = 0").
+		Updates(map[string]interface{}{"season_id": season.ID, "number": gorm.Expr("id")}).Error
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+}
+
+// migrateTravePassReachedLevels records levels users reached before
+// reached levels were recorded, all season levels up to the user
+// level are taken as reached and already seen.
+func migrateTravePassReachedLevels() {
+	err := db.DB.Exec(`
+		INSERT INTO trave_pass_reached_levels (user_id, level_id, season_id, seen_at, created_at)
+		SELECT p.user_id, l.id, l.season_id, NOW(), NOW()
+		FROM trave_pass_season_progresses p
+		JOIN trave_pass_levels l
+			ON l.season_id = p.season_id AND l.number > 0 AND l.number <= p.level_number
+		ORDER BY p.user_id, l.number
+		ON CONFLICT DO NOTHING`).Error
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+}
+
+// migrateBenefitItems moves item names of benefits created before
+// the item catalogue into catalogue physical items.
+func migrateBenefitItems() {
+	var legacyItems []struct {
+		ID       int64
+		ItemName string
+	}
+	err := db.DB.Table("benefit_items").Select("id, item_name").
+		Where("item_id IS NULL OR item_id = 0").Find(&legacyItems).Error
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+
+	for _, legacyItem := range legacyItems {
+		item := items.Item{Name: legacyItem.ItemName, Kind: items.ItemKindPhysical}
+		if err = db.DB.Create(&item).Error; err != nil {
+			logger.Fatal("%v", err)
+		}
+
+		err = db.DB.Model(&benefits.BenefitItem{}).Where("id = ?", legacyItem.ID).
+			Updates(map[string]interface{}{"item_id": item.ID, "quantity": 1}).Error
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+	}
+}
+
+// migrateBenefitProgressesExpiry sets ExpiresAt of benefit progresses
+// given before benefits expired, see SetMissingBenefitProgressesExpiry.
+func migrateBenefitProgressesExpiry() {
+	if err := benefit_progress.SetMissingBenefitProgressesExpiry(nil); err != nil {
+		logger.Fatal("%v", err)
 	}
 }
 
@@ -294,7 +432,7 @@ func createBinaryOptionReq(req requirements.RequirementBinaryOption, level int64
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementBinaryOptionType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func createMiniGameReq(req requirements.RequirementMiniGame, level int64) {
@@ -303,7 +441,7 @@ func createMiniGameReq(req requirements.RequirementMiniGame, level int64) {
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementMiniGameType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func createClickerReq(req requirements.RequirementClicker, level int64) {
@@ -312,7 +450,7 @@ func createClickerReq(req requirements.RequirementClicker, level int64) {
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementClickerType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func createExchangeReq(req requirements.RequirementExchange, level int64) {
@@ -321,7 +459,7 @@ func createExchangeReq(req requirements.RequirementExchange, level int64) {
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementExchangeType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func createReplenishmentReq(req requirements.RequirementReplenishment, level int64) {
@@ -330,7 +468,7 @@ func createReplenishmentReq(req requirements.RequirementReplenishment, level int
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementReplenishmentType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func createTurnoverReq(req requirements.RequirementTurnover, level int64) {
@@ -339,7 +477,7 @@ func createTurnoverReq(req requirements.RequirementTurnover, level int64) {
 		PolymorphicRequirementID: req.ID, PolymorphicRequirementType: requirements.RequirementTurnoverType}
 	db.DB.Create(&requirement).Scan(&requirement)
 	db.DB.Create(&travepass.TravePassLevelRequirement{
-		TravePassLevelID: level, RequirementID: requirement.ID})
+		TravePassLevelID: seedLevelIDs[level], RequirementID: requirement.ID})
 }
 
 func applyTravePassLevelBenefits() {
@@ -468,14 +606,14 @@ func applyTravePassLevelBenefits() {
 		BCoinsAmount: 90000}, 55) // same level
 	createCreditBenefit(benefits.BenefitCredit{
 		BCoinsAmount: 50000}, 56)
-	createItemBenefit(benefits.BenefitItem{
-		ItemName: "iPhone 15 256gb"}, 57)
+	createItemBenefit(items.Item{
+		Name: "iPhone 15 256gb", Kind: items.ItemKindPhysical}, 57)
 	createFortuneWheelBenefit(benefits.BenefitFortuneWheel{
 		FreeSpinsAmount: 15}, 58)
-	createItemBenefit(benefits.BenefitItem{
-		ItemName: "Unlock the chance to win a MacBook Air 13 M2 256GB"}, 59)
-	createItemBenefit(benefits.BenefitItem{
-		ItemName: "Chevrolet Camaro 2024, valued at 2,700,000 INR"}, 60)
+	createItemBenefit(items.Item{
+		Name: "Unlock the chance to win a MacBook Air 13 M2 256GB", Kind: items.ItemKindPhysical}, 59)
+	createItemBenefit(items.Item{
+		Name: "Chevrolet Camaro 2024, valued at 2,700,000 INR", Kind: items.ItemKindPhysical}, 60)
 	createCreditBenefit(benefits.BenefitCredit{
 		BCoinsAmount: 1000000}, 60) // same level
 }
@@ -486,7 +624,7 @@ func createFortuneWheelBenefit(ben benefits.BenefitFortuneWheel, level int64) {
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitFortuneWheelType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 func createBinaryOptionBenefit(ben benefits.BenefitBinaryOption, level int64) {
@@ -495,7 +633,7 @@ func createBinaryOptionBenefit(ben benefits.BenefitBinaryOption, level int64) {
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitBinaryOptionType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 func createClickerBenefit(ben benefits.BenefitClicker, level int64) {
@@ -504,7 +642,7 @@ func createClickerBenefit(ben benefits.BenefitClicker, level int64) {
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitClickerType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 func createCreditBenefit(ben benefits.BenefitCredit, level int64) {
@@ -513,7 +651,7 @@ func createCreditBenefit(ben benefits.BenefitCredit, level int64) {
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitCreditType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 func createMiniGameBenefit(ben benefits.BenefitMiniGame, level int64) {
@@ -522,16 +660,18 @@ func createMiniGameBenefit(ben benefits.BenefitMiniGame, level int64) {
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitMiniGameType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
-func createItemBenefit(ben benefits.BenefitItem, level int64) {
+func createItemBenefit(item items.Item, level int64) {
+	db.DB.Create(&item).Scan(&item)
+	ben := benefits.BenefitItem{ItemID: item.ID, Quantity: 1}
 	db.DB.Create(&ben).Scan(&ben)
 	benefit := benefits.Benefit{
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitItemType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 func createReplenishmentBenefit(ben benefits.BenefitReplenishment, level int64) {
@@ -540,7 +680,7 @@ func createReplenishmentBenefit(ben benefits.BenefitReplenishment, level int64)
 		PolymorphicBenefitID: ben.ID, PolymorphicBenefitType: benefits.BenefitReplenishmentType}
 	db.DB.Create(&benefit).Scan(&benefit)
 	db.DB.Create(&travepass.TravePassLevelBenefit{
-		TravePassLevelID: level, BenefitID: benefit.ID})
+		TravePassLevelID: seedLevelIDs[level], BenefitID: benefit.ID})
 }
 
 const (
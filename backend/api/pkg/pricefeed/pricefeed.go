@@ -0,0 +1,163 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"BlessedApi/pkg/logger"
+	"BlessedApi/pkg/redis"
+)
+
+// Feed is a source of 1s klines. Started feed writes klines
+// of its symbols into KlineStore until it is stopped.
+type Feed interface {
+	Start() error
+	Stop()
+}
+
+var errEmptySymbols = errors.New("feed has no symbols")
+
+type KlineData struct {
+	Symbol                   string  `json:"symbol"`
+	OpenTime                 int64   `json:"openTime"`
+	Open                     float64 `json:"open"`
+	High                     float64 `json:"high"`
+	Low                      float64 `json:"low"`
+	Close                    float64 `json:"close"`
+	Volume                   float64 `json:"volume"`
+	CloseTime                int64   `json:"closeTime"`
+	QuoteAssetVolume         float64 `json:"quoteAssetVolume"`
+	NumberOfTrades           int64   `json:"numberOfTrades"`
+	TakerBuyBaseAssetVolume  float64 `json:"takerBuyBaseAssetVolume"`
+	TakerBuyQuoteAssetVolume float64 `json:"takerBuyQuoteAssetVolume"`
+}
+
+// ErrKlineNotFound is returned by KlineStore reads if there is no such kline.
+var ErrKlineNotFound = errors.New("kline not found")
+
+// klinesKey returns Redis sorted set of symbol klines scored by open time.
+func klinesKey(symbol string) string {
+	return fmt.Sprintf("klines:%s", symbol)
+}
+
+// KlineStore keeps klines of the last klineRetention in Redis sorted
+// set per symbol, it's shared by all feed implementations. Latest kline
+// and klines by open time are read in O(log n).
+type KlineStore struct {
+	redisService *redis.RedisService
+}
+
+const klineRetention = 5 * time.Minute
+
+func NewKlineStore(redisService *redis.RedisService) *KlineStore {
+	return &KlineStore{redisService: redisService}
+}
+
+// Store adds kline or replaces kline with the same open time,
+// and trims klines older than klineRetention.
+func (s *KlineStore) Store(data KlineData) error {
+	ctx := context.Background()
+	key := klinesKey(data.Symbol)
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	openTime := strconv.FormatInt(data.OpenTime, 10)
+	cutoffTime := strconv.FormatInt(data.OpenTime-klineRetention.Milliseconds(), 10)
+
+	_, err = s.redisService.Client().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		// Kline is updated several times until it's closed
+		pipe.ZRemRangeByScore(ctx, key, openTime, openTime)
+		pipe.ZAdd(ctx, key, &goredis.Z{Score: float64(data.OpenTime), Member: dataBytes})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoffTime)
+		pipe.Expire(ctx, key, klineRetention)
+		return nil
+	})
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	return nil
+}
+
+// Latest returns the latest kline of symbol.
+func (s *KlineStore) Latest(ctx context.Context, symbol string) (KlineData, error) {
+	members, err := s.redisService.Client().ZRevRange(ctx, klinesKey(symbol), 0, 0).Result()
+	if err != nil {
+		return KlineData{}, logger.WrapError(err, "")
+	}
+
+	if len(members) == 0 {
+		return KlineData{}, ErrKlineNotFound
+	}
+
+	return unmarshalKline(members[0])
+}
+
+// At returns symbol kline opened at openTime.
+func (s *KlineStore) At(ctx context.Context, symbol string, openTime int64) (KlineData, error) {
+	score := strconv.FormatInt(openTime, 10)
+	members, err := s.redisService.Client().ZRangeByScore(ctx, klinesKey(symbol),
+		&goredis.ZRangeBy{Min: score, Max: score, Count: 1}).Result()
+	if err != nil {
+		return KlineData{}, logger.WrapError(err, "")
+	}
+
+	if len(members) == 0 {
+		return KlineData{}, ErrKlineNotFound
+	}
+
+	return unmarshalKline(members[0])
+}
+
+// Range returns symbol klines opened from fromOpenTime to toOpenTime
+// inclusive, the oldest first.
+func (s *KlineStore) Range(ctx context.Context, symbol string, fromOpenTime, toOpenTime int64) ([]KlineData, error) {
+	members, err := s.redisService.Client().ZRangeByScore(ctx, klinesKey(symbol), &goredis.ZRangeBy{
+		Min: strconv.FormatInt(fromOpenTime, 10),
+		Max: strconv.FormatInt(toOpenTime, 10),
+	}).Result()
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return unmarshalKlines(members)
+}
+
+// Window returns up to size latest klines of symbol, the oldest first.
+func (s *KlineStore) Window(ctx context.Context, symbol string, size int) ([]KlineData, error) {
+	members, err := s.redisService.Client().ZRange(ctx, klinesKey(symbol), int64(-size), -1).Result()
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	return unmarshalKlines(members)
+}
+
+func unmarshalKlines(members []string) ([]KlineData, error) {
+	klines := make([]KlineData, 0, len(members))
+	for _, member := range members {
+		kline, err := unmarshalKline(member)
+		if err != nil {
+			return nil, logger.WrapError(err, "")
+		}
+		klines = append(klines, kline)
+	}
+
+	return klines, nil
+}
+
+func unmarshalKline(data string) (KlineData, error) {
+	var kline KlineData
+	if err := json.Unmarshal([]byte(data), &kline); err != nil {
+		return KlineData{}, logger.WrapError(err, "")
+	}
+	return kline, nil
+}
@@ -0,0 +1,96 @@
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"BlessedApi/pkg/redis"
+)
+
+// newTestKlineStore returns KlineStore on Redis at
+// PRICE_FEED_TEST_REDIS_ADDR, test is skipped if it's not set.
+// Klines of symbol are removed after test.
+func newTestKlineStore(t *testing.T, symbol string) *KlineStore {
+	addr, ok := os.LookupEnv("PRICE_FEED_TEST_REDIS_ADDR")
+	if !ok {
+		t.Skip("PRICE_FEED_TEST_REDIS_ADDR is not set")
+	}
+
+	redisService := redis.NewRedisService(addr, "")
+	t.Cleanup(func() {
+		redisService.Client().Del(context.Background(), klinesKey(symbol))
+		redisService.Client().Close()
+	})
+
+	return NewKlineStore(redisService)
+}
+
+// Replayed klines are found by their open time like binary bets
+// look up price at expiration. Missing kline isn't found.
+func TestKlineStoreAtReplayedKlines(t *testing.T) {
+	const symbol = "PRICEFEEDTESTUSDT"
+	store := newTestKlineStore(t, symbol)
+
+	// Recording misses the 4th second
+	recording := `{"symbol":"PRICEFEEDTESTUSDT","openTime":1000,"closeTime":1999,"open":100,"close":100}
+{"symbol":"PRICEFEEDTESTUSDT","openTime":2000,"closeTime":2999,"open":100,"close":100.05}
+{"symbol":"PRICEFEEDTESTUSDT","openTime":3000,"closeTime":3999,"open":100.05,"close":99.9}
+{"symbol":"PRICEFEEDTESTUSDT","openTime":5000,"closeTime":5999,"open":99.9,"close":100.2}`
+	path := filepath.Join(t.TempDir(), "klines.jsonl")
+	if err := os.WriteFile(path, []byte(recording), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	klines, err := NewReplayFeed(store, path, []string{symbol}).loadKlines()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Truncate(time.Second).UnixMilli()
+	for i := range klines {
+		if err := store.Store(replayedKline(klines, startTime, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		atSec     int64
+		wantFound bool
+		wantClose float64
+	}{
+		{"first kline", 0, true, 100},
+		{"second kline", 1, true, 100.05},
+		{"third kline", 2, true, 99.9},
+		{"missing kline", 3, false, 0},
+		{"kline after gap", 4, true, 100.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			openTime := startTime + tt.atSec*time.Second.Milliseconds()
+			kline, err := store.At(ctx, symbol, openTime)
+			if !tt.wantFound {
+				if !errors.Is(err, ErrKlineNotFound) {
+					t.Fatalf("got %v, want ErrKlineNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if kline.OpenTime != openTime || kline.Close != tt.wantClose {
+				t.Errorf("got kline at %d closed at %v, want at %d closed at %v",
+					kline.OpenTime, kline.Close, openTime, tt.wantClose)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package pricefeed
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"BlessedApi/pkg/logger"
+)
+
+// RandomWalkFeed generates synthetic 1s klines. Close price of each
+// kline is the previous one moved by normally distributed relative
+// step with Volatility standard deviation, rounded to TickSize.
+// Prices never fall below TickSize.
+type RandomWalkFeed struct {
+	store      *KlineStore
+	symbols    []string
+	StartPrice float64
+	Volatility float64
+	TickSize   float64
+	rand       *rand.Rand
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+func NewRandomWalkFeed(store *KlineStore, symbols []string) *RandomWalkFeed {
+	return &RandomWalkFeed{
+		store:      store,
+		symbols:    symbols,
+		StartPrice: 100,
+		Volatility: 0.0005,
+		TickSize:   0.01,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:       make(chan struct{}),
+	}
+}
+
+func (f *RandomWalkFeed) Start() error {
+	if len(f.symbols) == 0 {
+		return logger.WrapError(errEmptySymbols, "")
+	}
+
+	logger.Info("Starting random walk price feed for %v", f.symbols)
+	go f.run()
+	return nil
+}
+
+func (f *RandomWalkFeed) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+func (f *RandomWalkFeed) run() {
+	prices := make(map[string]float64, len(f.symbols))
+	for _, symbol := range f.symbols {
+		prices[symbol] = f.StartPrice
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case now := <-ticker.C:
+			openTime := now.Truncate(time.Second).UnixMilli()
+			for _, symbol := range f.symbols {
+				kline := f.nextKline(symbol, prices[symbol], openTime)
+				prices[symbol] = kline.Close
+
+				if err := f.store.Store(kline); err != nil {
+					logger.Error("%v", err)
+				}
+			}
+		}
+	}
+}
+
+func (f *RandomWalkFeed) nextKline(symbol string, open float64, openTime int64) KlineData {
+	step := func(price float64) float64 {
+		return f.roundToTick(price * (1 + f.rand.NormFloat64()*f.Volatility))
+	}
+
+	close := step(open)
+	high := math.Max(math.Max(open, close), step(math.Max(open, close)))
+	low := math.Min(math.Min(open, close), step(math.Min(open, close)))
+	volume := roundPrice(f.rand.Float64() * 10)
+
+	return KlineData{
+		Symbol:                   symbol,
+		OpenTime:                 openTime,
+		Open:                     open,
+		High:                     high,
+		Low:                      low,
+		Close:                    close,
+		Volume:                   volume,
+		CloseTime:                openTime + time.Second.Milliseconds() - 1,
+		QuoteAssetVolume:         roundPrice(volume * close),
+		NumberOfTrades:           f.rand.Int63n(100),
+		TakerBuyBaseAssetVolume:  roundPrice(volume / 2),
+		TakerBuyQuoteAssetVolume: roundPrice(volume / 2 * close),
+	}
+}
+
+// roundToTick rounds price to the nearest multiple of TickSize,
+// but not below TickSize.
+func (f *RandomWalkFeed) roundToTick(price float64) float64 {
+	ticks := math.Max(math.Round(price/f.TickSize), 1)
+	return ticks / (1 / f.TickSize)
+}
+
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,72 @@
+package pricefeed
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRandomWalkFeedNextKline(t *testing.T) {
+	tests := []struct {
+		name       string
+		startPrice float64
+		volatility float64
+		tickSize   float64
+	}{
+		{"default feed", 100, 0.0005, 0.01},
+		{"high volatility near tick", 0.05, 0.5, 0.01},
+		{"coarse tick", 25000, 0.001, 0.5},
+		{"fine tick", 1.2345, 0.002, 0.0001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := NewRandomWalkFeed(nil, []string{"BTCUSDT"})
+			feed.StartPrice = tt.startPrice
+			feed.Volatility = tt.volatility
+			feed.TickSize = tt.tickSize
+			feed.rand = rand.New(rand.NewSource(1))
+
+			isTick := func(price float64) bool {
+				ticks := price / tt.tickSize
+				return math.Abs(ticks-math.Round(ticks)) < 1e-6
+			}
+
+			price := feed.roundToTick(feed.StartPrice)
+			openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+			for i := 0; i < 1000; i++ {
+				kline := feed.nextKline("BTCUSDT", price, openTime)
+
+				if kline.Open != price {
+					t.Fatalf("kline %d opens at %v, want previous close %v", i, kline.Open, price)
+				}
+				if kline.Low > math.Min(kline.Open, kline.Close) ||
+					kline.High < math.Max(kline.Open, kline.Close) {
+					t.Fatalf("kline %d prices out of range: %+v", i, kline)
+				}
+				if kline.Low <= 0 {
+					t.Fatalf("kline %d has not positive price: %+v", i, kline)
+				}
+				for _, p := range []float64{kline.Open, kline.High, kline.Low, kline.Close} {
+					if !isTick(p) {
+						t.Fatalf("kline %d price %v is not rounded to tick %v", i, p, tt.tickSize)
+					}
+				}
+				if kline.OpenTime != openTime || kline.CloseTime != openTime+999 {
+					t.Fatalf("kline %d times %d-%d, want %d-%d",
+						i, kline.OpenTime, kline.CloseTime, openTime, openTime+999)
+				}
+
+				price = kline.Close
+				openTime += time.Second.Milliseconds()
+			}
+		})
+	}
+}
+
+func TestRandomWalkFeedStopTwice(t *testing.T) {
+	feed := NewRandomWalkFeed(nil, []string{"BTCUSDT"})
+	feed.Stop()
+	feed.Stop()
+}
@@ -0,0 +1,125 @@
+package pricefeed
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"BlessedApi/pkg/logger"
+)
+
+// ReplayFeed replays klines recorded in a JSON lines file, one
+// KlineData per line. Kline times are shifted so the first recorded
+// kline opens at feed start, and recording is looped when it ends.
+type ReplayFeed struct {
+	store    *KlineStore
+	path     string
+	symbols  map[string]bool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewReplayFeed(store *KlineStore, path string, symbols []string) *ReplayFeed {
+	symbolsSet := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		symbolsSet[symbol] = true
+	}
+
+	return &ReplayFeed{
+		store:   store,
+		path:    path,
+		symbols: symbolsSet,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (f *ReplayFeed) Start() error {
+	klines, err := f.loadKlines()
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+
+	if len(klines) == 0 {
+		return logger.WrapError(fmt.Errorf("no klines of feed symbols in %s", f.path), "")
+	}
+
+	logger.Info("Replaying %d klines from %s", len(klines), f.path)
+	go f.replay(klines)
+	return nil
+}
+
+func (f *ReplayFeed) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+func (f *ReplayFeed) loadKlines() ([]KlineData, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+	defer file.Close()
+
+	var klines []KlineData
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var kline KlineData
+		if err := json.Unmarshal(scanner.Bytes(), &kline); err != nil {
+			return nil, logger.WrapError(fmt.Errorf("line %d: %w", line, err), "")
+		}
+
+		if f.symbols[kline.Symbol] {
+			klines = append(klines, kline)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, logger.WrapError(err, "")
+	}
+
+	sort.SliceStable(klines, func(i, j int) bool {
+		return klines[i].OpenTime < klines[j].OpenTime
+	})
+
+	return klines, nil
+}
+
+func (f *ReplayFeed) replay(klines []KlineData) {
+	startTime := time.Now().Truncate(time.Second).UnixMilli()
+
+	for i := 0; ; i++ {
+		kline := replayedKline(klines, startTime, i)
+
+		select {
+		case <-f.stop:
+			return
+		case <-time.After(time.Until(time.UnixMilli(kline.OpenTime))):
+		}
+
+		if err := f.store.Store(kline); err != nil {
+			logger.Error("%v", err)
+		}
+	}
+}
+
+// replayedKline returns i-th kline of the replay started at startTime.
+// Each loop of the recording is shifted by its duration, so looped
+// klines keep opening one after another.
+func replayedKline(klines []KlineData, startTime int64, i int) KlineData {
+	first := klines[0].OpenTime
+	period := klines[len(klines)-1].OpenTime - first + time.Second.Milliseconds()
+	shift := startTime - first + int64(i/len(klines))*period
+
+	kline := klines[i%len(klines)]
+	kline.OpenTime += shift
+	kline.CloseTime += shift
+	return kline
+}
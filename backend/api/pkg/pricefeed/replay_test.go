@@ -0,0 +1,137 @@
+package pricefeed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayFeedLoadKlines(t *testing.T) {
+	tests := []struct {
+		name          string
+		recording     string
+		symbols       []string
+		wantOpenTimes []int64
+		wantErr       bool
+	}{
+		{
+			name: "sorts klines by open time",
+			recording: `{"symbol":"BTCUSDT","openTime":3000,"close":3}
+{"symbol":"BTCUSDT","openTime":1000,"close":1}
+{"symbol":"BTCUSDT","openTime":2000,"close":2}`,
+			symbols:       []string{"BTCUSDT"},
+			wantOpenTimes: []int64{1000, 2000, 3000},
+		},
+		{
+			name: "skips other symbols and empty lines",
+			recording: `{"symbol":"BTCUSDT","openTime":1000,"close":1}
+
+{"symbol":"ETHUSDT","openTime":1000,"close":1}
+{"symbol":"BTCUSDT","openTime":2000,"close":2}
+`,
+			symbols:       []string{"BTCUSDT"},
+			wantOpenTimes: []int64{1000, 2000},
+		},
+		{
+			name: "keeps all feed symbols",
+			recording: `{"symbol":"BTCUSDT","openTime":1000,"close":1}
+{"symbol":"ETHUSDT","openTime":2000,"close":1}`,
+			symbols:       []string{"BTCUSDT", "ETHUSDT"},
+			wantOpenTimes: []int64{1000, 2000},
+		},
+		{
+			name:      "no klines of feed symbols",
+			recording: `{"symbol":"ETHUSDT","openTime":1000,"close":1}`,
+			symbols:   []string{"BTCUSDT"},
+		},
+		{
+			name: "invalid line",
+			recording: `{"symbol":"BTCUSDT","openTime":1000,"close":1}
+{"symbol":"BTCUSDT","openTime":`,
+			symbols: []string{"BTCUSDT"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "klines.jsonl")
+			if err := os.WriteFile(path, []byte(tt.recording), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			klines, err := NewReplayFeed(nil, path, tt.symbols).loadKlines()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(klines) != len(tt.wantOpenTimes) {
+				t.Fatalf("got %d klines, want %d", len(klines), len(tt.wantOpenTimes))
+			}
+			for i := range klines {
+				if klines[i].OpenTime != tt.wantOpenTimes[i] {
+					t.Errorf("kline %d opens at %d, want %d", i, klines[i].OpenTime, tt.wantOpenTimes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayFeedLoadKlinesMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.jsonl")
+	if _, err := NewReplayFeed(nil, path, []string{"BTCUSDT"}).loadKlines(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestReplayedKline(t *testing.T) {
+	// Recording of 3 seconds, replay period is 3000ms
+	klines := []KlineData{
+		{Symbol: "BTCUSDT", OpenTime: 1000, CloseTime: 1999, Close: 1},
+		{Symbol: "BTCUSDT", OpenTime: 2000, CloseTime: 2999, Close: 2},
+		{Symbol: "BTCUSDT", OpenTime: 3000, CloseTime: 3999, Close: 3},
+	}
+	const startTime = 60000
+
+	tests := []struct {
+		name          string
+		i             int
+		wantOpenTime  int64
+		wantCloseTime int64
+		wantClose     float64
+	}{
+		{"first kline opens at start", 0, 60000, 60999, 1},
+		{"last kline of first loop", 2, 62000, 62999, 3},
+		{"second loop follows first", 3, 63000, 63999, 1},
+		{"third loop", 7, 67000, 67999, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kline := replayedKline(klines, startTime, tt.i)
+			if kline.OpenTime != tt.wantOpenTime || kline.CloseTime != tt.wantCloseTime {
+				t.Errorf("got kline %d-%d, want %d-%d",
+					kline.OpenTime, kline.CloseTime, tt.wantOpenTime, tt.wantCloseTime)
+			}
+			if kline.Close != tt.wantClose {
+				t.Errorf("got close %v, want %v", kline.Close, tt.wantClose)
+			}
+		})
+	}
+
+	if klines[0].OpenTime != 1000 {
+		t.Error("recorded klines are modified")
+	}
+}
+
+func TestReplayFeedStopTwice(t *testing.T) {
+	feed := NewReplayFeed(nil, "klines.jsonl", []string{"BTCUSDT"})
+	feed.Stop()
+	feed.Stop()
+}
@@ -64,3 +64,21 @@ func (r *RedisService) DeleteKey(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+// GetHashField retrieves the value of a hash field from Redis
+func (r *RedisService) GetHashField(ctx context.Context, key, field string) (string, error) {
+	val, err := r.client.HGet(ctx, key, field).Result()
+	if err != nil {
+		return "", logger.WrapError(err, "")
+	}
+	return val, nil
+}
+
+// SetHashField sets the value of a hash field in Redis
+func (r *RedisService) SetHashField(ctx context.Context, key, field string, value interface{}) error {
+	err := r.client.HSet(ctx, key, field, value).Err()
+	if err != nil {
+		return logger.WrapError(err, "")
+	}
+	return nil
+}